@@ -0,0 +1,328 @@
+package maptrans
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// toSlice converts any slice or array to []interface{}
+func toSlice(src interface{}) ([]interface{}, error) {
+	if s, ok := src.([]interface{}); ok {
+		return s, nil
+	}
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%v is not an array", src)
+	}
+	result := make([]interface{}, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result, nil
+}
+
+// mapSlice applies elem to each element of the slice and returns the results
+func mapSlice(src []interface{}, elem MapFunc) ([]interface{}, error) {
+	result := make([]interface{}, len(src))
+	for i, v := range src {
+		val, err := elem(v)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		result[i] = val
+	}
+	return result, nil
+}
+
+// NewArrayLengthMap returns a MapFunc that verifies that an array has between
+// min and max elements (inclusive). A negative max means that there is no
+// upper bound. If elem is not nil, it is applied to each element and the
+// result is []interface{} with the mapped values, otherwise the source value
+// is returned unchanged.
+func NewArrayLengthMap(min, max int, elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		if len(arr) < min || (max >= 0 && len(arr) > max) {
+			if max < 0 {
+				return nil, fmt.Errorf("array has %d elements, expected at least %d",
+					len(arr), min)
+			}
+			return nil, fmt.Errorf("array has %d elements, expected %d to %d",
+				len(arr), min, max)
+		}
+		if elem == nil {
+			return src, nil
+		}
+		return mapSlice(arr, elem)
+	}
+}
+
+// NewDedupeMap returns a MapFunc that removes duplicate elements from an
+// array, preserving the order in which elements are first seen. If elem is
+// not nil it is applied to each element before comparison, so elements that
+// map to the same value are treated as duplicates. The result is
+// []interface{}. Elements should be comparable values such as strings or
+// numbers.
+func NewDedupeMap(elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		if elem != nil {
+			if arr, err = mapSlice(arr, elem); err != nil {
+				return nil, err
+			}
+		}
+		seen := make(map[interface{}]bool, len(arr))
+		result := make([]interface{}, 0, len(arr))
+		for i, v := range arr {
+			if v != nil && !reflect.TypeOf(v).Comparable() {
+				return nil, fmt.Errorf("element %d: %T is not comparable",
+					i, v)
+			}
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+			result = append(result, v)
+		}
+		return result, nil
+	}
+}
+
+// NewArrayIndexMap returns a MapFunc that extracts a single element from an
+// array. Negative index counts from the end of the array, so -1 is the last
+// element. If elem is not nil, it is applied to the extracted element.
+func NewArrayIndexMap(index int, elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		i := index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, fmt.Errorf("index %d is out of range for %d elements",
+				index, len(arr))
+		}
+		if elem == nil {
+			return arr[i], nil
+		}
+		return elem(arr[i])
+	}
+}
+
+// NewArrayFilterMap returns a MapFunc that keeps only array elements for which
+// keep returns true. The predicate is applied to the source elements. If elem
+// is not nil, it is applied to each kept element. The result is []interface{}.
+// Unlike SkipInvalid, elements are dropped intentionally and errors from elem
+// are still reported.
+func NewArrayFilterMap(keep func(interface{}) bool, elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]interface{}, 0, len(arr))
+		for i, v := range arr {
+			if !keep(v) {
+				continue
+			}
+			if elem != nil {
+				if v, err = elem(v); err != nil {
+					return nil, fmt.Errorf("element %d: %v", i, err)
+				}
+			}
+			result = append(result, v)
+		}
+		return result, nil
+	}
+}
+
+// NewArrayToMapMap returns a MapFunc that pivots an array of objects into an
+// object keyed by the keyField value of each element. Each element is
+// translated using sub, so sub decides whether keyField is also kept in the
+// values. Key values should be strings or integers. Missing and duplicate keys
+// are rejected.
+func NewArrayToMapMap(keyField string,
+	sub map[string]interface{}) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{}, len(arr))
+		for i, v := range arr {
+			elem, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("element %d: %v is not an object",
+					i, v)
+			}
+			keyVal, isPresent := elem[keyField]
+			if !isPresent {
+				return nil, fmt.Errorf("element %d: %v", i,
+					NewMissingAttributeError(keyField))
+			}
+			key, err := pivotKey(keyVal)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			if _, isDup := result[key]; isDup {
+				return nil, fmt.Errorf("element %d: duplicate key '%s'",
+					i, key)
+			}
+			if result[key], err = Translate(elem, sub); err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return result, nil
+	}
+}
+
+// pivotKey converts a string or an integer value to a map key
+func pivotKey(val interface{}) (string, error) {
+	if str, ok := val.(string); ok {
+		return strings.TrimSpace(str), nil
+	}
+	i, err := toInt64(val)
+	if err != nil {
+		return "", fmt.Errorf("invalid key %v: %v", val, err)
+	}
+	return strconv.FormatInt(i, 10), nil
+}
+
+// NewMapToArrayMap returns a MapFunc that is the inverse of NewArrayToMapMap:
+// it converts an object of objects into an array. Each value is translated
+// using sub (nil copies the element) and its key is stored in the translated
+// element as keyField.
+// Elements are sorted by key so the output is stable. A translated element
+// that already has keyField is rejected.
+func NewMapToArrayMap(keyField string,
+	sub map[string]interface{}) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcMap, ok := src.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v is not an object", src)
+		}
+		keys := make([]string, 0, len(srcMap))
+		for k := range srcMap {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		result := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			elem, ok := srcMap[k].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: %v is not an object",
+					k, srcMap[k])
+			}
+			dst, err := Translate(elem, sub)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			if _, isPresent := dst[keyField]; isPresent {
+				return nil, fmt.Errorf("%s: key '%s' is already present",
+					k, keyField)
+			}
+			dst[keyField] = k
+			result = append(result, dst)
+		}
+		return result, nil
+	}
+}
+
+// NewArraySortMap returns a MapFunc that translates array elements using elem
+// (if not nil) and returns the results as a sorted []string. Elements which
+// are not strings after translation are rejected.
+func NewArraySortMap(elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		if elem != nil {
+			if arr, err = mapSlice(arr, elem); err != nil {
+				return nil, err
+			}
+		}
+		result := make([]string, len(arr))
+		for i, v := range arr {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("element %d: %v is not a string",
+					i, v)
+			}
+			result[i] = str
+		}
+		sort.Strings(result)
+		return result, nil
+	}
+}
+
+// NewArrayFlattenMap returns a MapFunc that flattens nested arrays into a
+// single []interface{}, e.g. [[1, 2], [3, [4]]] becomes [1, 2, 3, 4]. The
+// depth limits the number of nesting levels which are flattened: with depth 1
+// only arrays nested directly in the source are expanded, so the example
+// above produces [1, 2, 3, [4]]. Zero or negative depth flattens all levels.
+// If elem is not nil, it is applied to each element of the result.
+func NewArrayFlattenMap(depth int, elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		arr, err := toSlice(src)
+		if err != nil {
+			return nil, err
+		}
+		result := flattenSlice(arr, depth, []interface{}{})
+		if elem == nil {
+			return result, nil
+		}
+		return mapSlice(result, elem)
+	}
+}
+
+// flattenSlice appends elements of src to result expanding up to depth
+// levels of nested arrays. Non-positive depth means no limit.
+func flattenSlice(src []interface{}, depth int,
+	result []interface{}) []interface{} {
+	for _, v := range src {
+		nested, err := toSlice(v)
+		switch {
+		case v == nil || err != nil:
+			result = append(result, v)
+		case depth == 1:
+			result = append(result, nested...)
+		default:
+			result = flattenSlice(nested, depth-1, result)
+		}
+	}
+	return result
+}
+
+// NewArrayWrapMap returns a MapFunc that always produces an array: a single
+// value is wrapped into a single-element array and arrays are kept as they
+// are, e.g. both "x" and ["x"] become ["x"]. If elem is not nil, it is
+// applied to each element. A nil value becomes an empty array. The result is
+// []interface{}.
+func NewArrayWrapMap(elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		if src == nil {
+			return []interface{}{}, nil
+		}
+		arr, err := toSlice(src)
+		if err != nil {
+			arr = []interface{}{src}
+		}
+		if elem == nil {
+			return append([]interface{}{}, arr...), nil
+		}
+		return mapSlice(arr, elem)
+	}
+}
@@ -0,0 +1,233 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrayLengthMap(t *testing.T) {
+	t.Parallel()
+	lengthMap := NewArrayLengthMap(1, 3, nil)
+	res, err := lengthMap([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, res)
+	_, err = lengthMap([]string{})
+	assert.Error(t, err, "Error expected")
+	_, err = lengthMap([]interface{}{"a", "b", "c", "d"})
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "4")
+	}
+	_, err = lengthMap("a")
+	assert.Error(t, err, "Error expected")
+
+	res, err = NewArrayLengthMap(0, -1, IntegerMap)([]interface{}{1, "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"1", "2"}, res)
+	_, err = NewArrayLengthMap(0, -1, IntegerMap)([]interface{}{"x"})
+	assert.Error(t, err, "Error expected")
+}
+
+func TestDedupeMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewDedupeMap(nil)([]string{"b", "a", "b", "c", "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"b", "a", "c"}, res)
+
+	res, err = NewDedupeMap(StringToLowerMap)([]interface{}{"A", " a", "B"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, res)
+
+	_, err = NewDedupeMap(StringMap)([]interface{}{"a", 1})
+	assert.Error(t, err, "Error expected")
+	_, err = NewDedupeMap(nil)([]interface{}{[]string{"a"}})
+	assert.Error(t, err, "Error expected")
+	_, err = NewDedupeMap(nil)("a")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArrayIndexMap(t *testing.T) {
+	t.Parallel()
+	src := []interface{}{"a", " b ", "c"}
+	res, err := NewArrayIndexMap(0, nil)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", res)
+	res, err = NewArrayIndexMap(-2, StringMap)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", res)
+	res, err = NewArrayIndexMap(-1, nil)([]string{"x", "y"})
+	assert.NoError(t, err)
+	assert.Equal(t, "y", res)
+	_, err = NewArrayIndexMap(3, nil)(src)
+	assert.Error(t, err, "Error expected")
+	_, err = NewArrayIndexMap(-4, nil)(src)
+	assert.Error(t, err, "Error expected")
+	_, err = NewArrayIndexMap(0, nil)([]interface{}{})
+	assert.Error(t, err, "Error expected")
+	_, err = NewArrayIndexMap(0, IntegerMap)(src)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArrayFilterMap(t *testing.T) {
+	t.Parallel()
+	enabled := func(v interface{}) bool {
+		obj, ok := v.(map[string]interface{})
+		return ok && obj["enabled"] == true
+	}
+	res, err := NewArrayFilterMap(enabled, nil)([]interface{}{
+		map[string]interface{}{"name": "a", "enabled": true},
+		map[string]interface{}{"name": "b", "enabled": false},
+		"junk",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "a", "enabled": true},
+	}, res)
+
+	nonEmpty := func(v interface{}) bool { return v != "" }
+	res, err = NewArrayFilterMap(nonEmpty, IntegerMap)([]string{"1", "", "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"1", "3"}, res)
+	_, err = NewArrayFilterMap(nonEmpty, IntegerMap)([]string{"", "x"})
+	assert.Error(t, err, "Error expected")
+	_, err = NewArrayFilterMap(nonEmpty, nil)("a")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArrayToMapMap(t *testing.T) {
+	t.Parallel()
+	pivot := NewArrayToMapMap("id", map[string]interface{}{"name": "Name"})
+	res, err := pivot([]interface{}{
+		map[string]interface{}{"id": "a", "name": "foo"},
+		map[string]interface{}{"id": 2, "name": "bar"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"Name": "foo"},
+		"2": map[string]interface{}{"Name": "bar"},
+	}, res)
+
+	_, err = pivot([]interface{}{
+		map[string]interface{}{"id": "a"},
+		map[string]interface{}{"id": " a"},
+	})
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "duplicate")
+	}
+	for _, bad := range []interface{}{
+		"a",
+		[]interface{}{"a"},
+		[]interface{}{map[string]interface{}{"name": "foo"}},
+		[]interface{}{map[string]interface{}{"id": true}},
+		[]interface{}{map[string]interface{}{"id": "a", "name": 1}},
+	} {
+		_, err := pivot(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestMapToArrayMap(t *testing.T) {
+	t.Parallel()
+	sub := map[string]interface{}{"name": "Name"}
+	src := map[string]interface{}{
+		"b": map[string]interface{}{"name": "bar"},
+		"a": map[string]interface{}{"name": "foo"},
+	}
+	res, err := NewMapToArrayMap("id", sub)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "a", "Name": "foo"},
+		map[string]interface{}{"id": "b", "Name": "bar"},
+	}, res)
+
+	// Round trip through NewArrayToMapMap
+	back, err := NewArrayToMapMap("id",
+		map[string]interface{}{"Name": "name"})(res)
+	assert.NoError(t, err)
+	assert.Equal(t, src, back)
+
+	// Without sub the elements are copied, the source is not modified
+	res, err = NewMapToArrayMap("id", nil)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "a", "name": "foo"},
+		map[string]interface{}{"id": "b", "name": "bar"},
+	}, res)
+	assert.Equal(t, map[string]interface{}{"name": "foo"}, src["a"])
+
+	_, err = NewMapToArrayMap("Name", sub)(src)
+	assert.Error(t, err, "Error expected")
+	_, err = NewMapToArrayMap("id", sub)(map[string]interface{}{"a": 1})
+	assert.Error(t, err, "Error expected")
+	_, err = NewMapToArrayMap("id", sub)([]interface{}{})
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArraySortMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewArraySortMap(StringToLowerMap)([]interface{}{"b", " C", "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, res)
+	res, err = NewArraySortMap(nil)([]string{"b", "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, res)
+	res, err = NewArraySortMap(nil)([]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, res)
+	_, err = NewArraySortMap(nil)([]interface{}{"a", 1})
+	assert.Error(t, err, "Error expected")
+	_, err = NewArraySortMap(StringMap)([]interface{}{1})
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArrayFlattenMap(t *testing.T) {
+	t.Parallel()
+	src := []interface{}{
+		[]interface{}{1, 2},
+		[]interface{}{3, []interface{}{4}},
+		5,
+		[]string{},
+	}
+	res, err := NewArrayFlattenMap(0, nil)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5}, res)
+	res, err = NewArrayFlattenMap(1, nil)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3, []interface{}{4}, 5}, res)
+	res, err = NewArrayFlattenMap(-1, IntegerMap)(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"1", "2", "3", "4", "5"}, res)
+	res, err = NewArrayFlattenMap(0, nil)([][]string{{"a"}, {"b", "c"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, res)
+
+	_, err = NewArrayFlattenMap(1, IntegerMap)(src)
+	assert.Error(t, err, "Error expected")
+	_, err = NewArrayFlattenMap(0, nil)("a")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArrayWrapMap(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"tag": Description{TargetName: "tags", MapFunc: NewArrayWrapMap(StringMap)},
+	}
+	for _, src := range []interface{}{" x", []string{"x "}, []interface{}{"x"}} {
+		dst, err := Translate(map[string]interface{}{"tag": src}, descr)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"tags": []interface{}{"x"}}, dst)
+	}
+	res, err := NewArrayWrapMap(nil)(map[string]interface{}{"a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"a": 1}}, res)
+	res, err = NewArrayWrapMap(nil)([]int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, res)
+	res, err = NewArrayWrapMap(StringMap)(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{}, res)
+	_, err = NewArrayWrapMap(StringMap)(1)
+	assert.Error(t, err, "Error expected")
+	_, err = NewArrayWrapMap(StringMap)([]interface{}{"a", 1})
+	assert.Error(t, err, "Error expected")
+}
@@ -0,0 +1,39 @@
+package maptrans
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// EqualFoldCompare is a CompareFunc that compares two strings ignoring case
+// and leading and trailing spaces.
+func EqualFoldCompare(src interface{}, dst interface{}) (bool, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		return false, fmt.Errorf("%v is not a string", src)
+	}
+	dstStr, ok := dst.(string)
+	if !ok {
+		return false, fmt.Errorf("%v is not a string", dst)
+	}
+	return strings.EqualFold(strings.TrimSpace(srcStr),
+		strings.TrimSpace(dstStr)), nil
+}
+
+// NewToleranceCompare returns a CompareFunc that treats two numbers as equal
+// if they differ by no more than tolerance. Numbers may be represented as
+// numeric types or strings.
+func NewToleranceCompare(tolerance float64) CompareFunc {
+	return func(src interface{}, dst interface{}) (bool, error) {
+		srcVal, err := toFloat64(src)
+		if err != nil {
+			return false, err
+		}
+		dstVal, err := toFloat64(dst)
+		if err != nil {
+			return false, err
+		}
+		return math.Abs(srcVal-dstVal) <= tolerance, nil
+	}
+}
@@ -0,0 +1,40 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareFunc(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": Description{
+			TargetName:  "Name",
+			MapFunc:     StringToUpperMap,
+			CompareFunc: EqualFoldCompare,
+		},
+		"weight": Description{
+			MapFunc:     IDMap,
+			CompareFunc: NewToleranceCompare(0.01),
+		},
+	}
+	src := map[string]interface{}{"name": "foo", "weight": "1.005"}
+	dst := map[string]interface{}{"Name": "FOO", "weight": 1.0}
+	r, err := IsSimilar(src, dst, descr)
+	assert.NoError(t, err)
+	assert.True(t, r)
+
+	dst["weight"] = 1.1
+	r, err = IsSimilar(src, dst, descr)
+	assert.Error(t, err, "Error expected")
+	assert.False(t, r)
+
+	dst["weight"] = "x"
+	_, err = IsSimilar(src, dst, descr)
+	assert.Error(t, err, "Error expected")
+
+	delete(dst, "Name")
+	_, err = IsSimilar(src, dst, descr)
+	assert.Error(t, err, "Error expected")
+}
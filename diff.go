@@ -0,0 +1,207 @@
+package maptrans
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Diff compares two objects produced by translating with description and
+// returns the fields that differ. Keys of the result are target names and
+// values are taken from b, so a field removed in b is reported with a nil
+// value.
+//
+// Fields are compared using the IsSimilar rules: CompareFunc is used when set
+// (a comparison error counts as a difference), MapTranslation fields are
+// compared recursively and only changed nested fields are reported, and other
+// fields are compared for deep equality. A MapArrayTranslation field that
+// differs in any element is reported as the whole array from b. Fields that
+// aren't in description or are described as ignored are not compared. Fields
+// of a and b without their own description entry are compared according to
+// the Pattern entry producing them.
+//
+// A nil description (including a nil SubTranslation) means the objects were
+// copied as-is, so every key of a and b is compared for deep equality.
+func Diff(a, b map[string]interface{},
+	description map[string]interface{}) (map[string]interface{}, error) {
+	if description == nil {
+		return diffAll(a, b), nil
+	}
+	result := map[string]interface{}{}
+	described := map[string]bool{}
+	for k, mapDescr := range description {
+		if mapDescr == nil {
+			continue
+		}
+		if name, ok := mapDescr.(string); ok {
+			described[name] = true
+			if !reflect.DeepEqual(a[name], b[name]) {
+				result[name] = b[name]
+			}
+			continue
+		}
+		md, ok := mapDescr.(Description)
+		if !ok {
+			return nil, NewInternalError(
+				fmt.Sprintf("invalid description %v", mapDescr))
+		}
+		if md.Pattern {
+			continue
+		}
+		targetName := md.TargetName
+		if targetName == "" {
+			targetName = k
+		}
+		described[targetName] = true
+		if err := diffField(a, b, targetName, md, result); err != nil {
+			return nil, err
+		}
+	}
+	patterns := descriptionPatterns(targetPatterns(description))
+	if len(patterns) == 0 {
+		return result, nil
+	}
+	for _, m := range []map[string]interface{}{a, b} {
+		for name := range m {
+			if described[name] {
+				continue
+			}
+			described[name] = true
+			if md, ok := matchPattern(patterns, name); ok {
+				err := diffField(a, b, name, md.(Description), result)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// diffAll compares every key of a and b for deep equality and returns the keys
+// that differ with values taken from b
+func diffAll(a, b map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, vA := range a {
+		if vB, ok := b[k]; !ok || !reflect.DeepEqual(vA, vB) {
+			result[k] = vB
+		}
+	}
+	for k, vB := range b {
+		if _, ok := a[k]; !ok {
+			result[k] = vB
+		}
+	}
+	return result
+}
+
+// diffField compares the field name of a and b described by md and stores the
+// difference, if any, in result
+func diffField(a, b map[string]interface{}, name string, md Description,
+	result map[string]interface{}) error {
+	if md.Type == IgnoreTranslation {
+		return nil
+	}
+	vA, inA := a[name]
+	vB, inB := b[name]
+	if !inA && !inB {
+		return nil
+	}
+	if !inA || !inB {
+		result[name] = vB
+		return nil
+	}
+	changed, err := diffValues(vA, vB, md)
+	if err != nil {
+		return err
+	}
+	if changed != nil {
+		result[name] = changed
+	}
+	return nil
+}
+
+// targetPatterns returns the Pattern entries of description keyed by the
+// pattern of target names they produce. If several entries produce the same
+// pattern, the one with the lowest source key is used.
+func targetPatterns(
+	description map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(description))
+	for k := range description {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := map[string]interface{}{}
+	for _, k := range keys {
+		md, ok := description[k].(Description)
+		if !ok || !md.Pattern {
+			continue
+		}
+		target := k
+		if md.TargetName != "" {
+			target = md.TargetName
+		}
+		if _, isPresent := result[target]; !isPresent {
+			result[target] = md
+		}
+	}
+	return result
+}
+
+// diffValues compares two values of a field described by md. It returns nil
+// if the values are the same, otherwise the value to report.
+func diffValues(vA, vB interface{}, md Description) (interface{}, error) {
+	if md.CompareFunc != nil {
+		if same, err := md.CompareFunc(vA, vB); err != nil || !same {
+			return vB, nil
+		}
+		return nil, nil
+	}
+	switch md.Type {
+	case MapTranslation:
+		mapA, okA := vA.(map[string]interface{})
+		mapB, okB := vB.(map[string]interface{})
+		if !okA || !okB {
+			break
+		}
+		changed, err := Diff(mapA, mapB, md.SubTranslation)
+		if err != nil {
+			return nil, err
+		}
+		if len(changed) == 0 {
+			return nil, nil
+		}
+		return changed, nil
+	case MapArrayTranslation:
+		arrA, errA := toSlice(vA)
+		arrB, errB := toSlice(vB)
+		if errA != nil || errB != nil {
+			break
+		}
+		if len(arrA) != len(arrB) {
+			return vB, nil
+		}
+		for i := range arrA {
+			mapA, okA := arrA[i].(map[string]interface{})
+			mapB, okB := arrB[i].(map[string]interface{})
+			if !okA || !okB {
+				if !reflect.DeepEqual(arrA[i], arrB[i]) {
+					return vB, nil
+				}
+				continue
+			}
+			changed, err := Diff(mapA, mapB, md.SubTranslation)
+			if err != nil {
+				return nil, err
+			}
+			if len(changed) != 0 {
+				return vB, nil
+			}
+		}
+		return nil, nil
+	}
+	if reflect.DeepEqual(vA, vB) {
+		return nil, nil
+	}
+	return vB, nil
+}
@@ -0,0 +1,133 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"title": Description{TargetName: "Title", MapFunc: IDMap,
+			CompareFunc: EqualFoldCompare},
+		"note": Ignore,
+		"info": Description{
+			TargetName: "Info",
+			Type:       MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip":   "IP",
+				"port": Description{TargetName: "Port", MapFunc: IntegerMap},
+			},
+		},
+		"routes": Description{
+			TargetName: "Routes",
+			Type:       MapArrayTranslation,
+			SubTranslation: map[string]interface{}{
+				"gw": "GW",
+			},
+		},
+		"alias": "Alias",
+	}
+	src := map[string]interface{}{
+		"name":  "foo",
+		"title": "Boss",
+		"note":  "a",
+		"info":  map[string]interface{}{"ip": "1.1.1.1", "port": 80},
+		"routes": []interface{}{
+			map[string]interface{}{"gw": "1.1.1.254"},
+		},
+		"alias": "f",
+	}
+	a, err := Translate(src, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	diff, err := Diff(a, a, descr)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+
+	src["name"] = "bar"
+	src["title"] = " boss"
+	src["info"] = map[string]interface{}{"ip": "1.1.1.1", "port": "81"}
+	src["routes"] = []interface{}{
+		map[string]interface{}{"gw": "1.1.1.253"},
+	}
+	delete(src, "alias")
+	b, err := Translate(src, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	diff, err = Diff(a, b, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name":   "bar",
+		"Info":   map[string]interface{}{"Port": "81"},
+		"Routes": b["Routes"],
+		"Alias":  nil,
+	}, diff)
+
+	_, err = Diff(a, b, map[string]interface{}{"name": 1})
+	assert.Error(t, err, "Error expected")
+}
+
+func TestDiffPattern(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"id": "ID",
+		"meta_*": Description{
+			Pattern:    true,
+			TargetName: "Meta_*",
+			MapFunc:    IDMap,
+		},
+		"*_count": Description{Pattern: true, MapFunc: IntegerMap},
+		"tmp_*":   Description{Pattern: true, Type: IgnoreTranslation},
+	}
+	a := map[string]interface{}{
+		"ID": "1", "Meta_a": 1, "Meta_b": 2, "x_count": "1", "tmp_x": 1,
+	}
+	b := map[string]interface{}{
+		"ID": "1", "Meta_a": 1, "Meta_c": 3, "x_count": "2", "tmp_x": 2,
+	}
+	diff, err := Diff(a, b, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Meta_b":  nil,
+		"Meta_c":  3,
+		"x_count": "2",
+	}, diff)
+
+	diff, err = Diff(map[string]interface{}{"a": 1},
+		map[string]interface{}{"a": 2},
+		map[string]interface{}{"*": Description{Pattern: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 2}, diff)
+}
+
+func TestDiffNilDescription(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"info": Description{TargetName: "Info", Type: MapTranslation},
+	}
+	a := map[string]interface{}{
+		"Info": map[string]interface{}{"x": 1, "y": 1},
+	}
+	b := map[string]interface{}{
+		"Info": map[string]interface{}{"x": 2, "z": 1},
+	}
+	diff, err := Diff(a, b, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Info": map[string]interface{}{"x": 2, "y": nil, "z": 1},
+	}, diff)
+
+	diff, err = Diff(a, a, descr)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = Diff(map[string]interface{}{"a": 1},
+		map[string]interface{}{"a": 2}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 2}, diff)
+}
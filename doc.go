@@ -96,7 +96,19 @@ If we want to present this as a "flat"  object
 	  "fruit": "apple"
 	}
 
-we need a ObjectArrayTranslation method.
+we need a ModifyTranslation with a ModFunc which copies the keys of the
+embedded object into the destination. The Merge helper does the copying:
+
+	"value": maptrans.Description{
+		Type: maptrans.ModifyTranslation,
+		ModFunc: func(src, dst map[string]interface{}, v interface{}) error {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%v is not an object", v)
+			}
+			return maptrans.Merge(dst, obj, false)
+		},
+	},
 
 Example
 
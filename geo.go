@@ -0,0 +1,91 @@
+package maptrans
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Axis identifies the type of a geographic coordinate
+type Axis int
+
+const (
+	// Latitude is a coordinate in the range [-90, 90]
+	Latitude Axis = iota
+	// Longitude is a coordinate in the range [-180, 180]
+	Longitude
+)
+
+// Degrees-minutes-seconds notation, e.g. 40°26′46″N or 40 26' 46" N
+var validDMS = regexp.MustCompile(
+	`^(-)?(\d+(?:\.\d+)?)\s*[°d]\s*(?:(\d+(?:\.\d+)?)\s*['′m]\s*)?(?:(\d+(?:\.\d+)?)\s*(?:"|″|''|s)\s*)?([NSEWnsew])?$`)
+
+// NewCoordinateMap returns a MapFunc that converts a coordinate to decimal
+// degrees (float64). The source can be a number, a decimal string or a
+// string in degrees-minutes-seconds notation such as "40°26′46″N". The
+// result is verified to be within the range of the axis.
+func NewCoordinateMap(axis Axis) MapFunc {
+	limit := 90.0
+	hemispheres := "NS"
+	if axis == Longitude {
+		limit = 180.0
+		hemispheres = "EW"
+	}
+	return func(src interface{}) (interface{}, error) {
+		val, err := toFloat64(src)
+		if err != nil {
+			srcStr, ok := src.(string)
+			if !ok {
+				return nil, err
+			}
+			if val, err = parseDMS(strings.TrimSpace(srcStr),
+				hemispheres); err != nil {
+				return nil, err
+			}
+		}
+		if math.IsNaN(val) || math.Abs(val) > limit {
+			return nil, fmt.Errorf("%v is out of range [-%v, %v]",
+				src, limit, limit)
+		}
+		return val, nil
+	}
+}
+
+// parseDMS converts degrees-minutes-seconds notation to decimal degrees.
+// Hemisphere letter, if present, should be one of hemispheres.
+func parseDMS(src string, hemispheres string) (float64, error) {
+	m := validDMS.FindStringSubmatch(src)
+	if m == nil {
+		return 0, fmt.Errorf("%s is not a valid coordinate", src)
+	}
+	parts := [3]float64{}
+	for i, s := range m[2:5] {
+		if s == "" {
+			continue
+		}
+		// Can't fail since the regexp only matches numbers
+		parts[i], _ = strconv.ParseFloat(s, 64)
+	}
+	if parts[1] >= 60 || parts[2] >= 60 {
+		return 0, fmt.Errorf("%s has invalid minutes or seconds", src)
+	}
+	val := parts[0] + parts[1]/60 + parts[2]/3600
+	if hemisphere := strings.ToUpper(m[5]); hemisphere != "" {
+		if !strings.Contains(hemispheres, hemisphere) {
+			return 0, fmt.Errorf("%s has invalid hemisphere %s", src,
+				hemisphere)
+		}
+		if m[1] != "" {
+			return 0, fmt.Errorf("%s has both sign and hemisphere", src)
+		}
+		if hemisphere == "S" || hemisphere == "W" {
+			val = -val
+		}
+	}
+	if m[1] != "" {
+		val = -val
+	}
+	return val, nil
+}
@@ -0,0 +1,39 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoordinateMap(t *testing.T) {
+	t.Parallel()
+	lat := NewCoordinateMap(Latitude)
+	lon := NewCoordinateMap(Longitude)
+	res, err := lat(40.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 40.5, res)
+	res, err = lat("-33.5")
+	assert.NoError(t, err)
+	assert.Equal(t, -33.5, res)
+	res, err = lat("40°26′46″N")
+	assert.NoError(t, err)
+	assert.InDelta(t, 40.446111, res, 1e-6)
+	res, err = lat(`33° 52' 0" S`)
+	assert.NoError(t, err)
+	assert.InDelta(t, -33.866667, res, 1e-6)
+	res, err = lon("79°58′56″W")
+	assert.NoError(t, err)
+	assert.InDelta(t, -79.982222, res, 1e-6)
+	res, err = lon("151°12′E")
+	assert.NoError(t, err)
+	assert.InDelta(t, 151.2, res, 1e-6)
+
+	for _, bad := range []interface{}{91, "100°N", "40°26′46″E",
+		"40°61′N", "north", true, "-40°N"} {
+		_, err = lat(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+	_, err = lon(-181)
+	assert.Error(t, err, "Error expected")
+}
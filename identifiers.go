@@ -0,0 +1,94 @@
+package maptrans
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// normalizeDigits removes spaces and hyphens from the string and verifies
+// that the rest has the expected number of digits.
+func normalizeDigits(src interface{}, length int) (string, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		return "", fmt.Errorf("%v is not a string", src)
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, srcStr)
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%s contains non-digit characters",
+				srcStr)
+		}
+	}
+	if len(digits) != length {
+		return "", fmt.Errorf("%s has wrong length %d, expected %d",
+			srcStr, len(digits), length)
+	}
+	return digits, nil
+}
+
+// validEAN13Check verifies the EAN-13 check digit of a 13-digit string
+func validEAN13Check(digits string) bool {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(digits[12]-'0')
+}
+
+// EAN13Map verifies that the argument is a valid EAN-13 code and returns it as
+// a string of 13 digits. Spaces and hyphens are removed.
+func EAN13Map(src interface{}) (interface{}, error) {
+	digits, err := normalizeDigits(src, 13)
+	if err != nil {
+		return "", err
+	}
+	if !validEAN13Check(digits) {
+		return "", fmt.Errorf("%s has bad check digit", digits)
+	}
+	return digits, nil
+}
+
+// ISBN13Map verifies that the argument is a valid ISBN-13 and returns it as a
+// string of 13 digits. Spaces and hyphens are removed.
+func ISBN13Map(src interface{}) (interface{}, error) {
+	digits, err := normalizeDigits(src, 13)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+		return "", fmt.Errorf("%s has invalid ISBN prefix", digits)
+	}
+	if !validEAN13Check(digits) {
+		return "", fmt.Errorf("%s has bad check digit", digits)
+	}
+	return digits, nil
+}
+
+// NewUUIDVersionMap returns a MapFunc that translates a UUID like UUIDMap and
+// additionally verifies that it has the given version, e.g. 4 for random
+// UUIDs. The version is the first digit of the third group.
+func NewUUIDVersionMap(version int) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		res, err := UUIDMap(src)
+		if err != nil {
+			return "", err
+		}
+		uuid := res.(string)
+		actual, err := strconv.ParseInt(uuid[14:15], 16, 0)
+		if err != nil || int(actual) != version {
+			return "", fmt.Errorf("%s is not a version %d UUID", uuid,
+				version)
+		}
+		return uuid, nil
+	}
+}
@@ -0,0 +1,53 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEAN13Map(t *testing.T) {
+	t.Parallel()
+	res, err := EAN13Map("4006381333931")
+	assert.NoError(t, err)
+	assert.Equal(t, "4006381333931", res)
+	_, err = EAN13Map("4006381333932")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "check digit")
+	}
+	_, err = EAN13Map("400638133393")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "length")
+	}
+	_, err = EAN13Map("400638133393x")
+	assert.Error(t, err, "Error expected")
+	_, err = EAN13Map(4006381333931)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestISBN13Map(t *testing.T) {
+	t.Parallel()
+	res, err := ISBN13Map("978-0-306-40615-7")
+	assert.NoError(t, err)
+	assert.Equal(t, "9780306406157", res)
+	_, err = ISBN13Map("978-0-306-40615-8")
+	assert.Error(t, err, "Error expected")
+	_, err = ISBN13Map("4006381333931")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestUUIDVersionMap(t *testing.T) {
+	t.Parallel()
+	v4 := NewUUIDVersionMap(4)
+	res, err := v4(" 9b2f4c3e-8d1a-4f6b-a2c7-3e5d9f1b7a40 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "9b2f4c3e-8d1a-4f6b-a2c7-3e5d9f1b7a40", res)
+	_, err = v4("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "version 4")
+	}
+	_, err = v4("not-a-uuid")
+	assert.Error(t, err, "Error expected")
+	_, err = NewUUIDVersionMap(1)("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NoError(t, err)
+}
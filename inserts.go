@@ -0,0 +1,105 @@
+package maptrans
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// NewTemplateInsert returns an InsertFunc that renders the text/template tmpl
+// against the source map and inserts the resulting string, e.g.
+// "{{.first}} {{.last}}". The template is parsed once, so parse errors are
+// reported by NewTemplateInsert. Referring to a field missing from the source
+// map is an execution error.
+func NewTemplateInsert(tmpl string) (InsertFunc, error) {
+	t, err := template.New("insert").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return func(src map[string]interface{}, _ map[string]interface{},
+		_ string) (interface{}, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, src); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	}, nil
+}
+
+// NewCoalesceInsert returns an InsertFunc that inserts the value of the first
+// source field that is present and not empty (nil or a blank string). If
+// none of the sources has a value, the field is omitted (see ErrOmit).
+func NewCoalesceInsert(sources ...string) InsertFunc {
+	return func(src map[string]interface{}, _ map[string]interface{},
+		_ string) (interface{}, error) {
+		for _, name := range sources {
+			val, isPresent := src[name]
+			if !isPresent || val == nil {
+				continue
+			}
+			if str, ok := val.(string); ok && strings.TrimSpace(str) == "" {
+				continue
+			}
+			return val, nil
+		}
+		return nil, ErrOmit
+	}
+}
+
+// EmptyArrayInsert is an InsertFunc that inserts an empty array, so consumers
+// get [] rather than a missing key or null. Since InsertTranslation entries
+// only fill fields missing from the result, it is used together with the
+// description of the array field under a separate key. An explicit null in
+// the source would still be written as nil, so the array field should drop
+// nil values to let the insert replace them, e.g.
+//
+//	"tags": Description{
+//		TargetName: "Tags",
+//		MapFunc: NewConditionalDropMap(func(v interface{}) bool {
+//			return v == nil
+//		}, StringArrayMap),
+//	},
+//	"tags_default": Description{
+//		Type:       InsertTranslation,
+//		TargetName: "Tags",
+//		InsertFunc: EmptyArrayInsert,
+//	},
+func EmptyArrayInsert(_ map[string]interface{}, _ map[string]interface{},
+	_ string) (interface{}, error) {
+	return []interface{}{}, nil
+}
+
+// NewRequiredTogetherInsert returns an InsertFunc which verifies that either
+// all or none of the given fields are present in the source. Unlike
+// NewRequiredTogetherMod it runs for every translation, regardless of which
+// fields are present. It never inserts anything, so the description key only
+// names the check, e.g.
+//
+//	"dates": Description{
+//		Type:       InsertTranslation,
+//		InsertFunc: NewRequiredTogetherInsert("start_date", "end_date"),
+//	},
+//
+// The error is an InvalidPropertyError for the description key.
+func NewRequiredTogetherInsert(fields ...string) InsertFunc {
+	return func(src map[string]interface{}, _ map[string]interface{},
+		attr string) (interface{}, error) {
+		if err := checkRequiredTogether(src, fields); err != nil {
+			return nil, NewInvalidProp(attr, err.Error())
+		}
+		return nil, ErrOmit
+	}
+}
+
+// NewMutuallyExclusiveInsert returns an InsertFunc which verifies that at
+// most one of the given fields is present in the source. It is used like
+// NewRequiredTogetherInsert.
+func NewMutuallyExclusiveInsert(fields ...string) InsertFunc {
+	return func(src map[string]interface{}, _ map[string]interface{},
+		attr string) (interface{}, error) {
+		if err := checkMutuallyExclusive(src, fields); err != nil {
+			return nil, NewInvalidProp(attr, err.Error())
+		}
+		return nil, ErrOmit
+	}
+}
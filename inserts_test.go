@@ -0,0 +1,139 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateInsert(t *testing.T) {
+	t.Parallel()
+	insert, err := NewTemplateInsert("{{.first}} {{.last}}")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	descr := map[string]interface{}{
+		"first": "first",
+		"full_name": Description{
+			Type:       InsertTranslation,
+			TargetName: "full_name",
+			InsertFunc: insert,
+		},
+	}
+	src := map[string]interface{}{"first": "John", "last": "Smith"}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, "John Smith", dst["full_name"])
+
+	_, err = Translate(map[string]interface{}{"first": "John"}, descr)
+	assert.Error(t, err, "Error expected")
+
+	_, err = NewTemplateInsert("{{.first")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestCoalesceInsert(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"display": Description{
+			Type:       InsertTranslation,
+			TargetName: "display",
+			InsertFunc: NewCoalesceInsert("display_name", "name", "login"),
+		},
+	}
+	dst, err := Translate(map[string]interface{}{
+		"display_name": " ", "name": nil, "login": "jdoe",
+	}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"display": "jdoe"}, dst)
+
+	dst, err = Translate(map[string]interface{}{
+		"name": "John", "login": "jdoe",
+	}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"display": "John"}, dst)
+
+	dst, err = Translate(map[string]interface{}{}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, dst)
+}
+
+func TestEmptyArrayInsert(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"tags": Description{TargetName: "Tags", MapFunc: StringArrayMap},
+		"tags_default": Description{
+			Type:       InsertTranslation,
+			TargetName: "Tags",
+			InsertFunc: EmptyArrayInsert,
+		},
+	}
+	dst, err := Translate(map[string]interface{}{}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Tags": []interface{}{}}, dst)
+	dst, err = Translate(map[string]interface{}{"tags": []string{"a"}}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Tags": []string{"a"}}, dst)
+
+	// An explicit null is only replaced if the array field drops it
+	dst, err = Translate(map[string]interface{}{"tags": nil}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Tags": nil}, dst)
+	descr["tags"] = Description{
+		TargetName: "Tags",
+		MapFunc: NewConditionalDropMap(func(v interface{}) bool {
+			return v == nil
+		}, StringArrayMap),
+	}
+	dst, err = Translate(map[string]interface{}{"tags": nil}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Tags": []interface{}{}}, dst)
+}
+
+func TestRequiredTogetherInsert(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"start_date": "StartDate",
+		"end_date":   "EndDate",
+		"dates": Description{
+			Type:       InsertTranslation,
+			InsertFunc: NewRequiredTogetherInsert("start_date", "end_date"),
+		},
+	}
+	dst, err := Translate(map[string]interface{}{"start_date": "2020-01-01",
+		"end_date": "2020-02-01"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"StartDate": "2020-01-01",
+		"EndDate": "2020-02-01"}, dst)
+	_, err = Translate(map[string]interface{}{}, descr)
+	assert.NoError(t, err)
+	for _, src := range []map[string]interface{}{
+		{"start_date": "2020-01-01"}, {"end_date": "2020-02-01"},
+	} {
+		_, err = Translate(src, descr)
+		if assert.IsType(t, &InvalidPropertyError{}, err, src) {
+			assert.Equal(t, "dates", err.(*InvalidPropertyError).Name)
+		}
+	}
+}
+
+func TestMutuallyExclusiveInsert(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"a": "A",
+		"b": "B",
+		"c": "C",
+		"abc": Description{
+			Type:       InsertTranslation,
+			InsertFunc: NewMutuallyExclusiveInsert("a", "b", "c"),
+		},
+	}
+	_, err := Translate(map[string]interface{}{"b": "x"}, descr)
+	assert.NoError(t, err)
+	_, err = Translate(map[string]interface{}{}, descr)
+	assert.NoError(t, err)
+	_, err = Translate(map[string]interface{}{"b": "x", "c": "y"}, descr)
+	if assert.IsType(t, &InvalidPropertyError{}, err) {
+		assert.Contains(t, err.Error(), "b, c")
+	}
+}
@@ -0,0 +1,111 @@
+package maptrans
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Number as defined by JSON
+var validJSONNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// NewJSONStringTranslation returns a MapFunc for fields containing a JSON
+// encoded object. The string is decoded and the object is translated using
+// the sub description. The result is the translated object
+// (map[string]interface{}), not a JSON string.
+func NewJSONStringTranslation(sub map[string]interface{}) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", src)
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(srcStr), &obj); err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %v", err)
+		}
+		return Translate(obj, sub)
+	}
+}
+
+// NewBase64JSONTranslation returns a MapFunc for fields containing a base64
+// encoded JSON object, such as the payload segment of a JWT. Both standard and
+// URL-safe alphabets are accepted, with or without padding. The decoded object
+// is translated like in NewJSONStringTranslation. Invalid base64, invalid JSON
+// and translation failures are reported as distinct errors.
+func NewBase64JSONTranslation(sub map[string]interface{}) MapFunc {
+	jsonTranslation := NewJSONStringTranslation(sub)
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimRight(strings.TrimSpace(srcStr), "=")
+		encoding := base64.RawStdEncoding
+		if strings.ContainsAny(srcStr, "-_") {
+			encoding = base64.RawURLEncoding
+		}
+		data, err := encoding.DecodeString(srcStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64: %v", err)
+		}
+		return jsonTranslation(string(data))
+	}
+}
+
+// RawJSONMap converts a value to json.RawMessage so it is carried through
+// verbatim when the result is serialized. A json.RawMessage source is
+// verified to be well-formed JSON and returned unchanged, any other value is
+// marshaled to JSON.
+func RawJSONMap(src interface{}) (interface{}, error) {
+	if raw, ok := src.(json.RawMessage); ok {
+		if !json.Valid(raw) {
+			return nil, fmt.Errorf("invalid JSON '%s'", string(raw))
+		}
+		return raw, nil
+	}
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, fmt.Errorf("can't convert %v to JSON: %v", src, err)
+	}
+	return json.RawMessage(data), nil
+}
+
+// NumberMap verifies that the argument is a number and returns it as
+// json.Number, so it is serialized as a JSON number rather than a string. The
+// source can be json.Number, an integer or float type, or a numeric string.
+// The textual representation of json.Number and string values is preserved,
+// so large integers don't lose precision.
+func NumberMap(src interface{}) (interface{}, error) {
+	var str string
+	switch src := src.(type) {
+	case json.Number:
+		str = src.String()
+	case string:
+		str = strings.TrimSpace(src)
+	case float64:
+		str = strconv.FormatFloat(src, 'f', -1, 64)
+	case float32:
+		str = strconv.FormatFloat(float64(src), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		str = fmt.Sprint(src)
+	default:
+		return nil, fmt.Errorf("invalid type %T for value %v", src, src)
+	}
+	if !validJSONNumber.MatchString(str) {
+		return nil, fmt.Errorf("invalid value '%s' for a number", str)
+	}
+	return json.Number(str), nil
+}
+
+// JSONBoolMap is a strict variant of BoolMap which accepts only bool values.
+// Strings such as "true" or "1" and numbers are rejected.
+func JSONBoolMap(src interface{}) (interface{}, error) {
+	val, ok := src.(bool)
+	if !ok {
+		return nil, fmt.Errorf("invalid type %T for boolean value %v", src, src)
+	}
+	return val, nil
+}
@@ -0,0 +1,127 @@
+package maptrans
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONStringTranslation(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"payload": Description{
+			TargetName: "Payload",
+			MapFunc: NewJSONStringTranslation(map[string]interface{}{
+				"name": "Name",
+				"id":   Description{TargetName: "ID", MapFunc: IntegerMap},
+			}),
+		},
+	}
+	src := map[string]interface{}{"payload": `{"name": "foo", "id": 12}`}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo", "ID": "12"},
+		dst["Payload"])
+
+	src["payload"] = `{"name": "foo", "id": "x"}`
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+	src["payload"] = `{"name": `
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+	src["payload"] = `[1, 2]`
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+	src["payload"] = 1
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestRawJSONMap(t *testing.T) {
+	t.Parallel()
+	raw := json.RawMessage(`{"b": 1,  "a": [1.50, "x"]}`)
+	res, err := RawJSONMap(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, res)
+	res, err = RawJSONMap(map[string]interface{}{"a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{"a":1}`), res)
+
+	_, err = RawJSONMap(json.RawMessage(`{"a": `))
+	assert.Error(t, err, "Error expected")
+	_, err = RawJSONMap(func() {})
+	assert.Error(t, err, "Error expected")
+
+	dst, err := Translate(map[string]interface{}{"blob": raw},
+		map[string]interface{}{"blob": Description{MapFunc: RawJSONMap}})
+	assert.NoError(t, err)
+	data, err := json.Marshal(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"blob":{"b":1,"a":[1.50,"x"]}}`, string(data))
+}
+
+func TestNumberMap(t *testing.T) {
+	t.Parallel()
+	for src, expected := range map[interface{}]json.Number{
+		json.Number("9007199254740993"): "9007199254740993",
+		" 12.50 ":                       "12.50",
+		1.5:                             "1.5",
+		42:                              "42",
+		int64(-7):                       "-7",
+		int8(-8):                        "-8",
+		uint16(16):                      "16",
+	} {
+		res, err := NumberMap(src)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, res)
+	}
+	for _, bad := range []interface{}{"x", "0x10", "NaN", "01", true, nil} {
+		_, err := NumberMap(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestJSONBoolMap(t *testing.T) {
+	t.Parallel()
+	res, err := JSONBoolMap(true)
+	assert.NoError(t, err)
+	assert.Equal(t, true, res)
+	res, err = JSONBoolMap(false)
+	assert.NoError(t, err)
+	assert.Equal(t, false, res)
+	for _, bad := range []interface{}{"true", "1", 1, 0.0, nil} {
+		_, err := JSONBoolMap(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestBase64JSONTranslation(t *testing.T) {
+	t.Parallel()
+	trans := NewBase64JSONTranslation(map[string]interface{}{
+		"sub":  Description{TargetName: "Subject", MapFunc: StringMap},
+		"name": "Name",
+	})
+	payload := `{"sub":"1234","name":"Jöhn?>"}`
+	for _, enc := range []*base64.Encoding{base64.StdEncoding,
+		base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		res, err := trans(enc.EncodeToString([]byte(payload)))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"Subject": "1234",
+			"Name": "Jöhn?>"}, res)
+	}
+
+	_, err := trans("not base64!")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "base64")
+	}
+	_, err = trans(base64.StdEncoding.EncodeToString([]byte("[1]")))
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "JSON")
+	}
+	_, err = trans(base64.StdEncoding.EncodeToString([]byte(`{"sub": 1}`)))
+	assert.IsType(t, &InvalidPropertyError{}, err)
+	_, err = trans(1)
+	assert.Error(t, err, "Error expected")
+}
@@ -0,0 +1,173 @@
+package maptrans
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Names of translation types used by LoadDescription
+var translationTypeNames = map[string]TranslationType{
+	"":           CustomTranslation,
+	"custom":     CustomTranslation,
+	"map":        MapTranslation,
+	"map_array":  MapArrayTranslation,
+	"map_values": MapValueScalarTranslation,
+	"ignore":     IgnoreTranslation,
+}
+
+// descriptionSpec is the external representation of Description
+type descriptionSpec struct {
+	Target      string                     `json:"target"`
+	Type        string                     `json:"type"`
+	Mandatory   bool                       `json:"mandatory"`
+	MapFunc     string                     `json:"map_func"`
+	SkipInvalid bool                       `json:"skip_invalid"`
+	NilAsEmpty  bool                       `json:"nil_as_empty"`
+	OmitEmpty   bool                       `json:"omit_empty"`
+	Sub         map[string]json.RawMessage `json:"sub"`
+}
+
+// LoadDescription reads a description from a JSON or YAML document. This
+// allows keeping translations in configuration files rather than in Go code.
+// Documents which are not valid JSON are parsed as YAML.
+//
+// The document is an object where each value is either a string (a rename),
+// null (the field is dropped) or an object with the following optional keys:
+//
+//	target       - TargetName
+//	type         - "custom" (default), "map", "map_array", "map_values" or
+//	               "ignore"
+//	mandatory    - Mandatory
+//	map_func     - name of a MapFunc registered with RegisterMapFunc
+//	skip_invalid - SkipInvalid
+//	nil_as_empty - NilAsEmpty
+//	omit_empty   - OmitEmpty
+//	sub          - SubTranslation in the same format
+//
+// For example:
+//
+//	{
+//	  "name": "Name",
+//	  "id": {"target": "ID", "mandatory": true, "map_func": "UUIDMap"},
+//	  "info": {"target": "Info", "type": "map", "sub": {"ip": "IP"}}
+//	}
+//
+// or in YAML:
+//
+//	name: Name
+//	id: {target: ID, mandatory: true, map_func: UUIDMap}
+//	info:
+//	  target: Info
+//	  type: map
+//	  sub:
+//	    ip: IP
+//
+// Types that require Go functions other than MapFunc (ModifyTranslation and
+// InsertTranslation) can't be loaded.
+func LoadDescription(r io.Reader) (map[string]interface{}, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(data, &raw) != nil {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("invalid description: %v", err)
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid description: %v", err)
+		}
+	}
+	return parseDescription(raw, "")
+}
+
+// yamlToJSON converts a YAML document to JSON, so it can be parsed the same
+// way as JSON descriptions
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// parseDescription converts the external representation of a description
+// into the runtime one. The path is used for error messages.
+func parseDescription(raw map[string]json.RawMessage,
+	path string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		keyPath := fieldPath(path, k)
+		var value interface{}
+		if err := json.Unmarshal(v, &value); err != nil {
+			return nil, fmt.Errorf("%s: %v", keyPath, err)
+		}
+		switch value := value.(type) {
+		case nil:
+			result[k] = nil
+			continue
+		case string:
+			result[k] = value
+			continue
+		case map[string]interface{}:
+		default:
+			return nil, fmt.Errorf("%s: invalid description %v",
+				keyPath, value)
+		}
+		var spec descriptionSpec
+		dec := json.NewDecoder(bytes.NewReader(v))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("%s: %v", keyPath, err)
+		}
+		md, err := spec.description(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = md
+	}
+	return result, nil
+}
+
+// description converts the spec into Description
+func (spec *descriptionSpec) description(path string) (Description, error) {
+	translationType, ok := translationTypeNames[spec.Type]
+	if !ok {
+		return Description{}, fmt.Errorf("%s: unknown type '%s'",
+			path, spec.Type)
+	}
+	md := Description{
+		TargetName:  spec.Target,
+		Type:        translationType,
+		Mandatory:   spec.Mandatory,
+		MapFuncName: spec.MapFunc,
+		SkipInvalid: spec.SkipInvalid,
+		NilAsEmpty:  spec.NilAsEmpty,
+		OmitEmpty:   spec.OmitEmpty,
+	}
+	if spec.MapFunc != "" {
+		if _, ok := LookupMapFunc(spec.MapFunc); !ok {
+			return Description{}, fmt.Errorf("%s: unknown map_func '%s'",
+				path, spec.MapFunc)
+		}
+	}
+	switch translationType {
+	case CustomTranslation, MapValueScalarTranslation:
+		if spec.MapFunc == "" {
+			return Description{}, fmt.Errorf("%s: missing map_func", path)
+		}
+	case MapTranslation, MapArrayTranslation:
+		if spec.Sub != nil {
+			sub, err := parseDescription(spec.Sub, path)
+			if err != nil {
+				return Description{}, err
+			}
+			md.SubTranslation = sub
+		}
+	}
+	return md, nil
+}
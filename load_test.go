@@ -0,0 +1,119 @@
+package maptrans
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDescription(t *testing.T) {
+	t.Parallel()
+	config := `{
+		"name": "Name",
+		"internal": null,
+		"secret": {"type": "ignore"},
+		"id": {"target": "ID", "mandatory": true, "map_func": "UUIDMap"},
+		"info": {
+			"target": "Info",
+			"type": "map",
+			"sub": {
+				"port": {"map_func": "IntegerMap"},
+				"routes": {
+					"type": "map_array",
+					"skip_invalid": true,
+					"sub": {"gw": {"map_func": "IPAddrMap", "mandatory": true}}
+				}
+			}
+		}
+	}`
+	descr, err := LoadDescription(strings.NewReader(config))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	src := map[string]interface{}{
+		"name":     "foo",
+		"internal": 1,
+		"secret":   2,
+		"id":       "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"info": map[string]interface{}{
+			"port": 80,
+			"routes": []map[string]interface{}{
+				{"gw": "1.2.3.4"}, {"gw": "bad"},
+			},
+		},
+	}
+	dst, err := TranslateStrict(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name": "foo",
+		"ID":   "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"Info": map[string]interface{}{
+			"port":   "80",
+			"routes": []map[string]interface{}{{"gw": "1.2.3.4"}},
+		},
+	}, dst)
+
+	delete(src, "id")
+	_, err = Translate(src, descr)
+	assert.IsType(t, &MissingAttributeError{}, err)
+}
+
+func TestLoadDescriptionYAML(t *testing.T) {
+	t.Parallel()
+	config := `
+name: Name
+internal: ~
+id: {target: ID, mandatory: true, map_func: UUIDMap}
+info:
+  target: Info
+  type: map
+  sub:
+    port:
+      map_func: IntegerMap
+`
+	descr, err := LoadDescription(strings.NewReader(config))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]interface{}{
+		"name":     "Name",
+		"internal": nil,
+		"id": Description{TargetName: "ID", Mandatory: true,
+			MapFuncName: "UUIDMap"},
+		"info": Description{
+			TargetName: "Info",
+			Type:       MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"port": Description{MapFuncName: "IntegerMap"},
+			},
+		},
+	}, descr)
+
+	for _, config := range []string{
+		"a: [b",
+		"- a",
+		"a: {target: b}",
+		"a: {map_func: StringMap, unknown: true}",
+	} {
+		_, err := LoadDescription(strings.NewReader(config))
+		assert.Error(t, err, "Error expected for %s", config)
+	}
+}
+
+func TestLoadDescriptionBad(t *testing.T) {
+	t.Parallel()
+	for _, config := range []string{
+		`{"a": `,
+		`["a"]`,
+		`{"a": 1}`,
+		`{"a": {"map_func": "NoSuchMap"}}`,
+		`{"a": {"type": "insert", "map_func": "StringMap"}}`,
+		`{"a": {"target": "b"}}`,
+		`{"a": {"map_func": "StringMap", "unknown": true}}`,
+		`{"a": {"type": "map", "sub": {"b": {"map_func": "x"}}}}`,
+	} {
+		_, err := LoadDescription(strings.NewReader(config))
+		assert.Error(t, err, "Error expected for %s", config)
+	}
+}
@@ -1,12 +1,18 @@
 package maptrans
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/goinggo/mapstructure"
 )
@@ -28,13 +34,41 @@ const (
 	ModifyTranslation
 	// InsertTranslation inserts a missing value to an existing map
 	InsertTranslation
+	// IgnoreTranslation explicitly discards the source field
+	IgnoreTranslation
+	// MapValueScalarTranslation applies MapFunc to every value of a map
+	// preserving the keys
+	MapValueScalarTranslation
 )
 
+// ErrOmit may be returned by MapFunc, CtxMapFunc or InsertFunc to indicate
+// that the field should be left out of the result. It is not treated as an
+// error.
+var ErrOmit = errors.New("omit field")
+
+// Ignore is a description for source fields which are intentionally dropped.
+// Unlike fields missing from the description, ignored fields are accepted by
+// TranslateStrict.
+var Ignore = Description{Type: IgnoreTranslation}
+
 // MapFunc is a function that converts one interface to another. This is a
 // generic function that maps one value to some other value. All translations
 // are usually defined as MapFunc.
 type MapFunc func(interface{}) (interface{}, error)
 
+// CtxMapFunc is a context-aware variant of MapFunc. It is useful for
+// translations that need external state, e.g. to verify that a referenced
+// object exists. The context is the one passed to TranslateContext. If the
+// Description has Timeout set, the context is limited by it, so a slow
+// function should return when the context is done. Exceeding Timeout is
+// reported as InvalidPropertyError wrapping context.DeadlineExceeded. Timeout
+// has no effect on MapFunc, which can't be interrupted.
+type CtxMapFunc func(ctx context.Context, value interface{}) (interface{}, error)
+
+// KeyFunc transforms a source key before it is matched against the
+// description, e.g. to strip a prefix or to normalize case.
+type KeyFunc func(key string) string
+
 // ModFunc takes a source map(before translation), the destination map (with
 // some transations already applied) and a value and modifies the map. It
 // returns the error, if any.
@@ -45,6 +79,18 @@ type MapFunc func(interface{}) (interface{}, error)
 type ModFunc func(src map[string]interface{}, dst map[string]interface{},
 	value interface{}) error
 
+// SourcePredicate is a condition evaluated against the source map
+type SourcePredicate func(src map[string]interface{}) bool
+
+// CompareFunc is used by IsSimilar to compare a source value with the
+// corresponding destination value. It returns true if the values match;
+// otherwise it may return an error explaining the mismatch.
+type CompareFunc func(src interface{}, dst interface{}) (bool, error)
+
+// ResultValidator verifies invariants of the complete translation result,
+// e.g. that one field is not less than another.
+type ResultValidator func(result map[string]interface{}) error
+
 // InsertFunc is used to insert a new element into the map.
 // Parameters:
 //   Source map
@@ -59,12 +105,26 @@ type InsertFunc func(map[string]interface{}, map[string]interface{},
 // "name": Description
 // A SubTranslation is just another embedded translation for a field.
 type Description struct {
+	CompareFunc    CompareFunc            // Function to compare values in IsSimilar
+	CtxMapFunc     CtxMapFunc             // Context-aware MapFunc, used if set
+	Deprecated     bool                   // Warn when the source field is present
+	DeprecationMsg string                 // Optional deprecation warning details
+	ExpectedType   reflect.Kind           // Expected kind of MapFunc result
 	InsertFunc     InsertFunc             // Function to insert element
 	Mandatory      bool                   // The field must be present if true
+	MandatoryIf    SourcePredicate        // The field must be present if true for src
 	MapFunc        MapFunc                // Function that maps value to new value
+	MapFuncName    string                 // Name of registered MapFunc
 	ModFunc        ModFunc                // Function for object modification
+	NilAsEmpty     bool                   // Translate nil object as empty object
+	OmitEmpty      bool                   // Omit empty translated object
+	PassNonMaps    bool                   // Copy non-map array elements as is
+	Pattern        bool                   // The key is a pattern with '*'
+	Priority       int                    // Lower values are processed first
+	SkipInvalid    bool                   // Skip invalid array elements if true
 	SubTranslation map[string]interface{} // Sub-translation map for children
 	TargetName     string                 // Name of destination field
+	Timeout        time.Duration          // Time limit for CtxMapFunc, 0 means none
 	Type           TranslationType        // Type of translation
 }
 
@@ -84,6 +144,22 @@ func NewInternalError(reason string) *InternalError {
 	return &InternalError{Reason: reason}
 }
 
+// UnknownAttributeError is caused by a source attribute that isn't mentioned
+// in the description when translating in strict mode
+type UnknownAttributeError struct {
+	Name string
+}
+
+func (e *UnknownAttributeError) Error() string {
+	return fmt.Sprintf("unknown attribute '%s'", e.Name)
+}
+
+// NewUnknownAttributeError returns an instance of an error for an unknown
+// attribute
+func NewUnknownAttributeError(name string) *UnknownAttributeError {
+	return &UnknownAttributeError{Name: name}
+}
+
 // MissingAttributeError is caused by a map attribute that is mandatory but is
 // missing
 type MissingAttributeError struct {
@@ -104,17 +180,84 @@ func NewMissingAttributeError(name string) *MissingAttributeError {
 type InvalidPropertyError struct {
 	Name   string
 	Reason string
+	Err    error // Underlying error, if any
 }
 
 func (e *InvalidPropertyError) Error() string {
 	return fmt.Sprintf("property '%s' is invalid: %s", e.Name, e.Reason)
 }
 
+// Unwrap returns the underlying error
+func (e *InvalidPropertyError) Unwrap() error {
+	return e.Err
+}
+
 // NewInvalidProp returns an instance of InvalidPropertyError
 func NewInvalidProp(name string, reason string) *InvalidPropertyError {
 	return &InvalidPropertyError{Name: name, Reason: reason}
 }
 
+// FieldError is an error for a specific field identified by its path in the
+// source map. Nested fields are separated by '.' and array elements are
+// identified by their index, e.g. "info.routes[1].gateway".
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err.Error())
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// NewFieldError returns an instance of FieldError for the given path
+func NewFieldError(path string, err error) *FieldError {
+	return &FieldError{Path: path, Err: err}
+}
+
+// TranslationErrors is a list of errors collected during translation
+type TranslationErrors []*FieldError
+
+func (e TranslationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// TranslateErrorsToMap converts errors returned by TranslateAll to a map
+// from field path to error message, e.g. for rendering form validation
+// errors. Messages of InvalidPropertyError only contain the reason. Errors
+// that are not TranslationErrors are reported under the empty key. Messages
+// of several errors for the same path are joined with "; ". A nil error
+// produces a nil map.
+func TranslateErrorsToMap(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	errs, ok := err.(TranslationErrors)
+	if !ok {
+		return map[string]string{"": err.Error()}
+	}
+	result := make(map[string]string, len(errs))
+	for _, e := range errs {
+		msg := e.Err.Error()
+		if propErr, ok := e.Err.(*InvalidPropertyError); ok {
+			msg = propErr.Reason
+		}
+		if prev, isPresent := result[e.Path]; isPresent {
+			msg = prev + "; " + msg
+		}
+		result[e.Path] = msg
+	}
+	return result
+}
+
 var (
 	// Rather then using complete UUID package we test for valid UUID based on
 	// regexp match
@@ -123,6 +266,9 @@ var (
 	// Usual definition of an identifier - starts with a letter, followed by
 	// some number of letters or numbers or underscores
 	validID = regexp.MustCompile(`^[a-zA-Z_]+[0-9a-zA-Z_]*$`)
+
+	// Hostname label as defined by RFC 1123
+	validHostLabel = regexp.MustCompile(`^[0-9a-z]([0-9a-z-]{0,61}[0-9a-z])?$`)
 )
 
 // Translate is the main function that converts source map[string]interface{} to
@@ -135,16 +281,29 @@ var (
 //
 // - If TranslationType in the description is CustomTranslation, the MapFunc is
 // called on the source value, the result is written in the destination map
-// using TargetName as a key.
+// using TargetName as a key. If CtxMapFunc is set, it is called instead of
+// MapFunc (see TranslateContext). Instead of MapFunc, the description may
+// specify MapFuncName referring to a function registered with
+// RegisterMapFunc. If ExpectedType is set, the kind of the
+// result is verified and a mismatch is reported as an InternalError. If the
+// function returns ErrOmit, nothing is written.
 //
 // - If TranslationType is MapTranslation, it means that the source value is a
 // map that requires further translation which we apply using SubTranslation
-// definition. The result is written using TargetName as a key.
+// definition. The result is written using TargetName as a key. A nil source
+// value is written as nil unless NilAsEmpty is set, in which case it is
+// translated as an empty map. If OmitEmpty is set, nothing is written when the
+// translated map is empty or nil. Mandatory only requires the source field to
+// be present, so a mandatory field may still be omitted from the result.
 //
 // - If TranslationType is MapArrayTranslation, the source is an array of
 // objects (maps). In this case each element is translated using SubTranslation
 // as the description and the resulting array of objects is written using
-// TargetName as the key
+// TargetName as the key. If SkipInvalid is set, elements that fail translation
+// are dropped from the result instead of failing the whole translation; their
+// errors are only reported through WithStats and WithWarnings. If
+// PassNonMaps is set, elements which are not objects are copied as is and the
+// result is []interface{} rather than []map[string]interface{}.
 //
 // - If TranslationType is ModifyTranslation, we pass the source and destination
 // maps together with the field value to the ModFunc and it is up to it to put
@@ -152,38 +311,658 @@ var (
 //
 // - If TranslationType is InsertTranslation, we are inserting key that isn't in
 // the source map. In this case we call the InsertFunc and it inserts value (or
-// values) in the destination map.
+// values) in the destination map. If the InsertFunc returns ErrOmit, nothing
+// is inserted.
+//
+// - If TranslationType is MapValueScalarTranslation, the source is a map with
+// arbitrary keys. MapFunc is applied to every value and the resulting map with
+// the same keys is written using TargetName as the key. Entries for which
+// MapFunc returns ErrOmit are left out. Entries are processed in the order of
+// their keys. A nil source value is handled as for MapTranslation.
+//
+// - If TranslationType is IgnoreTranslation (see Ignore), the field is dropped.
+//
+// - If the description of a field is nil, the field is dropped as well. This
+// allows descriptions to mention fields that are handled elsewhere.
+//
+// A Description with Pattern set applies to all source fields which match its
+// key and don't have their own entry, e.g. "meta_*" matches "meta_color". The
+// key may contain a single '*' wildcard. A '*' in TargetName is replaced by
+// the part of the source field name matched by the wildcard, so TargetName "*"
+// strips the "meta_" prefix. If the resulting TargetName is empty, the field
+// name is preserved. A non-empty TargetName of a pattern with a wildcard must
+// contain '*', otherwise translation fails with InternalError. If several
+// patterns match a field, the longest one wins.
+//
+// Source fields are processed in a deterministic order: by the Priority of
+// their descriptions (lower first) and then by name. Inserted fields are
+// processed after all source fields in the same order. This allows a ModFunc
+// or InsertFunc to rely on fields with lower Priority being already present
+// in the destination map.
+//
+// Translation never modifies the description or the source map, so a single
+// description may be shared by any number of concurrent translations, as long
+// as the functions it refers to are safe for concurrent use.
 func Translate(src map[string]interface{},
 	description map[string]interface{}) (map[string]interface{}, error) {
+	return TranslateWithOptions(src, description)
+}
+
+// TranslateAll is similar to Translate but doesn't stop at the first invalid
+// field. Instead it translates as much as possible and returns all errors
+// as TranslationErrors sorted by field path, with array indices in numeric
+// order. The result is nil if there are any errors.
+func TranslateAll(src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{}, error) {
+	return TranslateWithOptions(src, description, WithCollectErrors())
+}
+
+// TranslateContext is similar to Translate but passes ctx to CtxMapFunc
+// functions. Translation stops with the context error once ctx is done.
+func TranslateContext(ctx context.Context, src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{}, error) {
+	return TranslateWithOptions(src, description, WithContext(ctx))
+}
+
+// TranslateWithKeyFunc is similar to Translate but applies keyFunc to every
+// source key (at every level) before matching it against the description.
+// Functions that receive the source map (ModFunc, InsertFunc) see the
+// transformed keys. If two source keys are transformed into the same key, an
+// InvalidPropertyError is returned for the key which is last in sorted order.
+func TranslateWithKeyFunc(src map[string]interface{},
+	description map[string]interface{},
+	keyFunc KeyFunc) (map[string]interface{}, error) {
+	return TranslateWithOptions(src, description, WithKeyFunc(keyFunc))
+}
+
+// TranslateStats provides counters describing a translation run. Fields are
+// counted at every nesting level.
+type TranslateStats struct {
+	Translated int                  // Source fields successfully translated
+	Dropped    int                  // Undescribed or ignored source fields
+	Inserted   int                  // Fields inserted by InsertFunc
+	Errors     int                  // Validation errors
+	Failures   map[FieldFailure]int // Validation errors by field and category
+}
+
+// FieldFailure identifies validation errors of a field by category. Array
+// indices are removed from the path, e.g. "routes[3].gw" becomes
+// "routes[].gw", so failures of all elements are counted together.
+// Categories are "missing" (MissingAttributeError), "invalid"
+// (InvalidPropertyError), "unknown" (UnknownAttributeError), "internal"
+// (InternalError) and "other".
+type FieldFailure struct {
+	Path     string
+	Category string
+}
+
+// Array indices in field paths
+var pathIndex = regexp.MustCompile(`\[\d+\]`)
+
+// errorCategory returns the FieldFailure category of the error
+func errorCategory(err error) string {
+	var missing *MissingAttributeError
+	var invalid *InvalidPropertyError
+	var unknown *UnknownAttributeError
+	var internal *InternalError
+	switch {
+	case errors.As(err, &missing):
+		return "missing"
+	case errors.As(err, &invalid):
+		return "invalid"
+	case errors.As(err, &unknown):
+		return "unknown"
+	case errors.As(err, &internal):
+		return "internal"
+	}
+	return "other"
+}
+
+func (s *TranslateStats) translated() {
+	if s != nil {
+		s.Translated++
+	}
+}
+
+func (s *TranslateStats) dropped() {
+	if s != nil {
+		s.Dropped++
+	}
+}
+
+func (s *TranslateStats) inserted() {
+	if s != nil {
+		s.Inserted++
+	}
+}
+
+func (s *TranslateStats) failed(path string, err error) {
+	if s != nil {
+		s.Errors++
+		if s.Failures == nil {
+			s.Failures = map[FieldFailure]int{}
+		}
+		s.Failures[FieldFailure{
+			Path:     pathIndex.ReplaceAllString(path, "[]"),
+			Category: errorCategory(err),
+		}]++
+	}
+}
+
+// TranslateWithStats is similar to Translate but also returns counters
+// describing the translation. Stats are returned even if translation fails.
+func TranslateWithStats(src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{},
+	TranslateStats, error) {
+	stats := TranslateStats{}
+	result, err := TranslateWithOptions(src, description, WithStats(&stats))
+	return result, stats, err
+}
+
+// TranslateWithKeys is similar to Translate but also returns the sorted list
+// of paths of source fields that matched a description entry (see FieldError
+// for the path format). Callers may compare it with the source keys to find
+// fields that were not mapped.
+func TranslateWithKeys(src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{},
+	[]string, error) {
+	keys := []string{}
+	result, err := TranslateWithOptions(src, description,
+		WithConsumedKeys(&keys))
+	return result, keys, err
+}
+
+// TranslateStrict is similar to Translate but it rejects source fields that
+// are not mentioned in the description with UnknownAttributeError. This
+// applies to nested translations as well. Fields that are dropped on purpose
+// should be described as Ignore.
+func TranslateStrict(src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{}, error) {
+	return TranslateWithOptions(src, description, WithStrict())
+}
+
+// TranslateMerge translates src using each of the descriptions and merges
+// the results into a single map. Descriptions are expected to produce
+// disjoint sets of fields: if two descriptions produce the same target name,
+// an InternalError is returned.
+func TranslateMerge(src map[string]interface{},
+	descriptions ...map[string]interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for _, description := range descriptions {
+		trans, err := Translate(src, description)
+		if err != nil {
+			return nil, err
+		}
+		if err := Merge(result, trans, false); err != nil {
+			return nil, NewInternalError(
+				fmt.Sprintf("conflicting target name: %v", err))
+		}
+	}
+	return result, nil
+}
+
+// RenameKeys is a lightweight alternative to Translate for pure renaming. It
+// returns a shallow copy of src with keys renamed according to renames. If
+// keepUnlisted is true, keys that are not in renames are copied unchanged,
+// otherwise they are dropped. A renamed key takes precedence over an unlisted
+// key with the same name. If several keys are renamed to the same name, the
+// lowest source key wins.
+func RenameKeys(src map[string]interface{}, renames map[string]string,
+	keepUnlisted bool) map[string]interface{} {
+	result := make(map[string]interface{}, len(src))
+	if keepUnlisted {
+		for k, v := range src {
+			if _, isRenamed := renames[k]; !isRenamed {
+				result[k] = v
+			}
+		}
+	}
+	renamed := map[string]bool{}
+	for _, k := range orderedKeys(src, nil) {
+		name, isRenamed := renames[k]
+		if !isRenamed || renamed[name] {
+			continue
+		}
+		renamed[name] = true
+		result[name] = src[k]
+	}
+	return result
+}
+
+// DeepCopyDescription returns a copy of description which doesn't share any
+// maps with the original, including nested SubTranslations, so the copy can
+// be modified safely. Functions referenced by descriptions are shared.
+func DeepCopyDescription(
+	description map[string]interface{}) map[string]interface{} {
+	if description == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(description))
+	for k, v := range description {
+		if md, ok := v.(Description); ok {
+			md.SubTranslation = DeepCopyDescription(md.SubTranslation)
+			v = md
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// translator keeps the state of a single translation
+type translator struct {
+	collect     bool               // Collect errors instead of failing fast
+	errs        TranslationErrors  // Collected errors
+	strict      bool               // Reject source fields missing from description
+	ctx         context.Context    // Context for CtxMapFunc
+	keyFunc     KeyFunc            // Transformation of source keys
+	stats       *TranslateStats    // Translation counters
+	maxDepth    int                // Maximum nesting depth, 0 means unlimited
+	depth       int                // Current nesting depth
+	guardModify bool               // Prevent ModFunc from overwriting keys
+	consumed    *[]string          // Paths of source fields matching description
+	maxFields   int                // Maximum number of keys in a map, 0 means unlimited
+	warnings    *[]string          // Warnings about deprecated fields and skips
+	originalKey string             // Result key for source values, if set
+	validator   ResultValidator    // Validation of the complete result
+	transforms  map[string]MapFunc // Final transformations of result fields
+	typeMode    TypeMismatchMode   // Handling of wrong-typed nested values
+	output      map[string]bool    // Target names to keep, nil keeps all
+}
+
+// run performs the translation of the top-level source map
+func (t *translator) run(src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{}, error) {
+	result, err := t.translate(src, description, "")
+	if err != nil {
+		return nil, err
+	}
+	if description == nil {
+		// The source is returned as is, copy it before post-processing
+		result = RenameKeys(result, nil, true)
+	}
+	if t.consumed != nil {
+		sort.Strings(*t.consumed)
+	}
+	if t.warnings != nil {
+		sort.Strings(*t.warnings)
+	}
+	if err := t.transformResult(result); err != nil {
+		return nil, err
+	}
+	if t.validator != nil && len(t.errs) == 0 {
+		if err := t.validator(result); err != nil {
+			return nil, err
+		}
+	}
+	if t.originalKey != "" && len(t.errs) == 0 {
+		if _, isPresent := result[t.originalKey]; isPresent {
+			return nil, NewInternalError(fmt.Sprintf(
+				"key '%s' for original values is already present",
+				t.originalKey))
+		}
+		original := make(map[string]interface{}, len(src))
+		for k, v := range src {
+			original[k] = v
+		}
+		result[t.originalKey] = original
+	}
+	if t.output != nil {
+		for k := range result {
+			if !t.output[k] {
+				delete(result, k)
+			}
+		}
+	}
+	if len(t.errs) > 0 {
+		sort.SliceStable(t.errs, func(i, j int) bool {
+			return pathLess(t.errs[i].Path, t.errs[j].Path)
+		})
+		return nil, t.errs
+	}
+	return result, nil
+}
+
+// pathLess orders field paths lexicographically except that array indices are
+// compared numerically, so "routes[2]" comes before "routes[10]"
+func pathLess(a, b string) bool {
+	for a != "" && b != "" {
+		if a[0] == '[' && b[0] == '[' {
+			endA, endB := strings.IndexByte(a, ']'), strings.IndexByte(b, ']')
+			if endA > 0 && endB > 0 {
+				iA, errA := strconv.Atoi(a[1:endA])
+				iB, errB := strconv.Atoi(b[1:endB])
+				if errA == nil && errB == nil && iA != iB {
+					return iA < iB
+				}
+			}
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// transformResult applies the final transformations to the fields of the
+// top-level result. Fields missing from the result are skipped.
+func (t *translator) transformResult(result map[string]interface{}) error {
+	names := make([]string, 0, len(t.transforms))
+	for name := range t.transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val, isPresent := result[name]
+		if !isPresent {
+			continue
+		}
+		newVal, err := t.transforms[name](val)
+		if errors.Is(err, ErrOmit) {
+			delete(result, name)
+			continue
+		}
+		if err != nil {
+			if err = t.fail(name,
+				NewInvalidProp(name, err.Error())); err != nil {
+				return err
+			}
+			delete(result, name)
+			continue
+		}
+		result[name] = newVal
+	}
+	return nil
+}
+
+// context returns the translation context
+func (t *translator) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// fail handles the error for the field at the given path. When errors are
+// collected, it records the error and returns nil, so the caller can skip the
+// field and proceed. Otherwise the error is returned as is.
+func (t *translator) fail(path string, err error) error {
+	t.stats.failed(path, err)
+	if !t.collect {
+		return err
+	}
+	t.errs = append(t.errs, NewFieldError(path, err))
+	return nil
+}
+
+// warnDeprecated records a warning about the deprecated field at the given path
+func (t *translator) warnDeprecated(path string, msg string) {
+	if t.warnings == nil {
+		return
+	}
+	warning := fmt.Sprintf("field '%s' is deprecated", path)
+	if msg != "" {
+		warning += ": " + msg
+	}
+	*t.warnings = append(*t.warnings, warning)
+}
+
+// warnSkipped records a warning about the invalid array element at the given
+// path which is dropped because of SkipInvalid
+func (t *translator) warnSkipped(path string, err error) {
+	if t.warnings != nil {
+		*t.warnings = append(*t.warnings,
+			fmt.Sprintf("element '%s' skipped: %v", path, err))
+	}
+}
+
+// patternEntry is a Pattern entry of a description
+type patternEntry struct {
+	key   string
+	descr Description
+}
+
+// descriptionPatterns returns the Pattern entries of description in the
+// matching order: the longest first, with ties broken by the lexicographic
+// order. It returns nil if there are no Pattern entries.
+func descriptionPatterns(description map[string]interface{}) []patternEntry {
+	var patterns []patternEntry
+	for k, v := range description {
+		if md, ok := v.(Description); ok && md.Pattern {
+			patterns = append(patterns, patternEntry{key: k, descr: md})
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		ki, kj := patterns[i].key, patterns[j].key
+		if len(ki) != len(kj) {
+			return len(ki) > len(kj)
+		}
+		return ki < kj
+	})
+	return patterns
+}
+
+// matchPattern returns the description for a key which doesn't have its own
+// entry but matches one of the patterns returned by descriptionPatterns. The
+// first matching pattern is used. The '*' in the TargetName of the pattern is
+// replaced by the part of the key matched by the wildcard.
+func matchPattern(patterns []patternEntry, key string) (interface{}, bool) {
+	for _, p := range patterns {
+		capture, ok := wildcardMatch(p.key, key)
+		if !ok {
+			continue
+		}
+		md := p.descr
+		md.Pattern = false
+		if md.TargetName == "" {
+			md.TargetName = key
+		} else {
+			md.TargetName = strings.Replace(md.TargetName, "*", capture, 1)
+		}
+		return md, true
+	}
+	return nil, false
+}
+
+// resolveDescription returns the description of key: its own entry or, if
+// there is none, the matching pattern
+func resolveDescription(description map[string]interface{},
+	patterns []patternEntry, key string) (interface{}, bool) {
+	mapDescr, ok := description[key]
+	if md, isDescr := mapDescr.(Description); !ok || (isDescr && md.Pattern) {
+		return matchPattern(patterns, key)
+	}
+	return mapDescr, true
+}
+
+// wildcardMatch matches key against a pattern with a single '*' wildcard and
+// returns the part of the key matched by the wildcard. A pattern without '*'
+// only matches itself.
+func wildcardMatch(pattern string, key string) (string, bool) {
+	i := strings.Index(pattern, "*")
+	if i < 0 {
+		return "", pattern == key
+	}
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	if len(key) < len(prefix)+len(suffix) ||
+		!strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) {
+		return "", false
+	}
+	return key[len(prefix) : len(key)-len(suffix)], true
+}
+
+// orderedKeys returns the keys of m in the processing order: by Priority of
+// their descriptions (lower first) and then by name
+func orderedKeys(m map[string]interface{},
+	description map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	if !hasPriority(description) {
+		sort.Strings(keys)
+		return keys
+	}
+	priority := func(k string) int {
+		if md, ok := description[k].(Description); ok {
+			return md.Priority
+		}
+		return 0
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := priority(keys[i]), priority(keys[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// hasPriority returns true if any entry of description sets Priority
+func hasPriority(description map[string]interface{}) bool {
+	for _, v := range description {
+		if md, ok := v.(Description); ok && md.Priority != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// describedKey is a source key with its resolved description
+type describedKey struct {
+	name        string
+	descr       interface{}
+	isDescribed bool // false if neither an entry nor a pattern matches
+}
+
+// describeKeys resolves the description of every key of src, using the
+// matching Pattern entry for keys without their own entry, and returns them
+// in the processing order of orderedKeys
+func describeKeys(src map[string]interface{},
+	description map[string]interface{}) []describedKey {
+	patterns := descriptionPatterns(description)
+	keys := make([]describedKey, 0, len(src))
+	for _, k := range orderedKeys(src, nil) {
+		mapDescr, ok := resolveDescription(description, patterns, k)
+		keys = append(keys, describedKey{name: k, descr: mapDescr,
+			isDescribed: ok})
+	}
+	if !hasPriority(description) {
+		return keys
+	}
+	priority := func(k describedKey) int {
+		if md, ok := k.descr.(Description); ok {
+			return md.Priority
+		}
+		return 0
+	}
+	// Keys are already sorted by name
+	sort.SliceStable(keys, func(i, j int) bool {
+		return priority(keys[i]) < priority(keys[j])
+	})
+	return keys
+}
+
+// fieldPath returns the path of the field name within the parent path
+func fieldPath(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func (t *translator) translate(src map[string]interface{},
+	description map[string]interface{},
+	path string) (map[string]interface{}, error) {
+	t.depth++
+	defer func() { t.depth-- }()
+	// Limit violations are reported for the whole object, so in the collect
+	// mode the object is left out without looking at its fields
+	if t.maxDepth > 0 && t.depth > t.maxDepth {
+		return nil, t.fail(path, NewInvalidProp(path,
+			fmt.Sprintf("maximum nesting depth %d exceeded", t.maxDepth)))
+	}
+	if t.maxFields > 0 && len(src) > t.maxFields {
+		return nil, t.fail(path, NewInvalidProp(path,
+			fmt.Sprintf("%d fields exceed maximum of %d", len(src), t.maxFields)))
+	}
 	if description == nil {
 		// nil description interpreted as 'no translation'
 		return src, nil
 	}
+	if t.keyFunc != nil {
+		var err error
+		if src, err = t.transformKeys(src, path); err != nil {
+			return nil, err
+		}
+	}
 	result := map[string]interface{}{}
 	// Check whether any mandatory field is missing
 	for k, v := range description {
-		// String translations are never mandatory
-		if _, isString := v.(string); isString {
+		// String and nil translations are never mandatory
+		if _, isString := v.(string); isString || v == nil {
 			continue // Nothing to do
 		}
 		md, ok := v.(Description)
 		if !ok {
-			return nil, NewInternalError(
-				fmt.Sprintf("%v is not Description", v))
+			err := t.fail(fieldPath(path, k), NewInternalError(
+				fmt.Sprintf("%v is not Description", v)))
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
-		if md.Mandatory {
+		if md.Pattern {
+			// A TargetName without '*' would map all matching fields to
+			// the same target
+			if strings.Contains(k, "*") && md.TargetName != "" &&
+				!strings.Contains(md.TargetName, "*") {
+				err := t.fail(fieldPath(path, k), NewInternalError(
+					fmt.Sprintf("pattern %s has TargetName %s without '*'",
+						k, md.TargetName)))
+				if err != nil {
+					return nil, err
+				}
+			}
+			continue // Patterns are never mandatory
+		}
+		if md.Mandatory || (md.MandatoryIf != nil && md.MandatoryIf(src)) {
 			if _, isPresent := src[k]; !isPresent {
-				return nil, NewMissingAttributeError(k)
+				err := t.fail(fieldPath(path, k),
+					NewMissingAttributeError(k))
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
 	// Walk over all fields present in the source and translate them according
 	// to description
-	for attr, value := range src {
-		mapDescr, ok := description[attr]
-		// If the field doesn't have matching description, ignore it.
+	for _, key := range describeKeys(src, description) {
+		attr, mapDescr, ok := key.name, key.descr, key.isDescribed
+		value := src[attr]
+		attrPath := fieldPath(path, attr)
+		// If the field doesn't have matching description, ignore it unless
+		// we are in strict mode.
 		if !ok {
+			if t.strict {
+				err := t.fail(attrPath, NewUnknownAttributeError(attr))
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			t.stats.dropped()
+			continue
+		}
+		if t.consumed != nil {
+			*t.consumed = append(*t.consumed, attrPath)
+		}
+		// nil description means that the field is handled elsewhere
+		if mapDescr == nil {
+			t.stats.dropped()
 			continue
 		}
 		// The description can be either a string or Description
@@ -191,14 +970,23 @@ func Translate(src map[string]interface{},
 		if stringConversion, ok := mapDescr.(string); ok {
 			dstStr, err := StringMap(value)
 			if err != nil {
-				return result, NewInvalidProp(attr, err.Error())
+				if err = t.fail(attrPath,
+					NewInvalidProp(attr, err.Error())); err != nil {
+					return result, err
+				}
+				continue
 			}
 			// Save destination in the specified string
 			result[stringConversion] = dstStr
+			t.stats.translated()
 			continue
 		}
 		md, ok := mapDescr.(Description)
 		if !ok {
+			// Already reported by the mandatory check
+			if t.collect {
+				continue
+			}
 			return nil, NewInternalError(
 				fmt.Sprintf("%v is not a Description", mapDescr))
 		}
@@ -206,76 +994,40 @@ func Translate(src map[string]interface{},
 			// By default preserve the attribute name
 			md.TargetName = attr
 		}
-		switch md.Type {
-		case CustomTranslation:
-			// CustomTranslation should specify MapFunc
-			if md.MapFunc == nil {
-				return nil,
-					NewInternalError("missing translation func for " + attr)
-			}
-			dstStr, err := md.MapFunc(value)
-			if err != nil {
-				return nil, NewInvalidProp(attr, err.Error())
-			}
-			// Save destination in the specified string
-			result[md.TargetName] = dstStr
-		case MapTranslation:
-			// value should have type map[string]interface{}
-			srcMap, ok := value.(map[string]interface{})
-			if !ok {
-				return nil, NewInternalError(
-					fmt.Sprintf("invalid type for %v: %T",
-						value, value))
-			}
-			// Translate value according to SubTranslation
-			trans, err := Translate(srcMap, md.SubTranslation)
-			if err != nil {
-				return nil, err
-			}
-			result[md.TargetName] = trans
-		case MapArrayTranslation:
-			// Translate [ {... }, {...} ]
-			srcMaps := []map[string]interface{}{}
-			err := mapstructure.Decode(value, &srcMaps)
-			if err != nil {
-				return nil, NewInternalError(err.Error())
-			}
-			// Translate each value and combine results
-			res := make([]map[string]interface{}, len(srcMaps))
-			for i, val := range srcMaps {
-				trans, err := Translate(val,
-					md.SubTranslation)
-				if err != nil {
-					return nil, err
-				}
-				res[i] = trans
-			}
-			result[md.TargetName] = res
-		case ModifyTranslation:
-			// Modify result based on value. Shoud have ModFunc.
-			if md.ModFunc == nil {
-				return nil,
-					NewInternalError("missing translation func for " + attr)
-			}
-			err := md.ModFunc(src, result, value)
-			if err != nil {
-				return nil, NewInvalidProp(attr, err.Error())
-			}
-		case InsertTranslation:
-			// InsertTranslation is only used for missing fields
+		if md.Deprecated {
+			t.warnDeprecated(attrPath, md.DeprecationMsg)
+		}
+		nErrs := len(t.errs)
+		err := t.translateField(src, result, attr, attrPath, value, md)
+		if err == ErrOmit {
+			t.stats.dropped()
 			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(t.errs) > nErrs:
+			// The error is already counted
+		case md.Type == IgnoreTranslation || md.Type == InsertTranslation:
+			t.stats.dropped()
 		default:
-			return nil, NewInternalError("Invalid Translation type")
+			t.stats.translated()
 		}
 	}
 
 	// Now check whether any value should be inserted
-	for attr, value := range description {
-		if _, isString := value.(string); isString {
+	for _, attr := range orderedKeys(description, description) {
+		value := description[attr]
+		if _, isString := value.(string); isString || value == nil {
 			continue // Nothing to do
 		}
 		md, ok := value.(Description)
 		if !ok {
+			// Already reported by the mandatory check
+			if t.collect {
+				continue
+			}
 			return nil, NewInternalError(
 				fmt.Sprintf("%v is not a Description", value))
 		}
@@ -284,8 +1036,12 @@ func Translate(src map[string]interface{},
 			continue
 		}
 		if md.InsertFunc == nil {
-			return nil,
-				NewInternalError("missing translation func for " + attr)
+			err := t.fail(fieldPath(path, attr),
+				NewInternalError("missing translation func for "+attr))
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		// Skip anything that is already present
@@ -295,21 +1051,462 @@ func Translate(src map[string]interface{},
 
 		// Get the value to insert
 		val, err := md.InsertFunc(src, result, attr)
+		if errors.Is(err, ErrOmit) {
+			continue
+		}
 		if err != nil {
-			return nil, err
+			if err = t.fail(fieldPath(path, attr), err); err != nil {
+				return nil, err
+			}
+			continue
 		}
 		// Insert result
 		result[md.TargetName] = val
+		t.stats.inserted()
+	}
+	return result, nil
+}
+
+// guardedModify calls ModFunc on a copy of the result and verifies that it
+// didn't modify or remove any existing keys before merging new keys into the
+// result.
+func (t *translator) guardedModify(src map[string]interface{},
+	result map[string]interface{}, attr string, path string,
+	value interface{}, md Description) error {
+	dst := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		dst[k] = v
+	}
+	if err := md.ModFunc(src, dst, value); err != nil {
+		return t.fail(path, NewInvalidProp(attr, err.Error()))
+	}
+	for k, v := range result {
+		newVal, isPresent := dst[k]
+		if !isPresent || !reflect.DeepEqual(v, newVal) {
+			return t.fail(path, NewInternalError(
+				fmt.Sprintf("modification func for %s overwrote '%s'",
+					attr, k)))
+		}
+	}
+	return Merge(result, dst, true)
+}
+
+// transformKeys returns a copy of src with keyFunc applied to all keys
+func (t *translator) transformKeys(src map[string]interface{},
+	path string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(src))
+	// Iterate in sorted order so the same duplicate is reported every time
+	for _, k := range orderedKeys(src, nil) {
+		v := src[k]
+		key := t.keyFunc(k)
+		if _, isPresent := result[key]; isPresent {
+			err := t.fail(fieldPath(path, k), NewInvalidProp(k,
+				fmt.Sprintf("duplicate key '%s'", key)))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		result[key] = v
 	}
 	return result, nil
 }
 
+// translateField translates a single source field described by md and stores
+// the result in the result map. It returns an error only if the translation
+// should be aborted or ErrOmit if the field should be left out.
+func (t *translator) translateField(src map[string]interface{},
+	result map[string]interface{}, attr string, path string,
+	value interface{}, md Description) error {
+	if err := t.context().Err(); err != nil {
+		return err
+	}
+	// Resolve named MapFunc
+	mapFunc, err := resolveMapFunc(md)
+	if err != nil {
+		return t.fail(path, err)
+	}
+	md.MapFunc = mapFunc
+	switch md.Type {
+	case CustomTranslation:
+		// CustomTranslation should specify MapFunc or CtxMapFunc
+		var dstStr interface{}
+		var err error
+		switch {
+		case md.CtxMapFunc != nil:
+			dstStr, err = t.callCtxMapFunc(attr, value, md)
+		case md.MapFunc != nil:
+			dstStr, err = md.MapFunc(value)
+		default:
+			return t.fail(path,
+				NewInternalError("missing translation func for "+attr))
+		}
+		if errors.Is(err, ErrOmit) {
+			return ErrOmit
+		}
+		if errors.Is(err, context.DeadlineExceeded) && md.Timeout > 0 {
+			return t.fail(path, &InvalidPropertyError{Name: attr,
+				Reason: err.Error(), Err: err})
+		}
+		if err != nil {
+			return t.fail(path, NewInvalidProp(attr, err.Error()))
+		}
+		// Verify the result type if requested
+		if md.ExpectedType != reflect.Invalid {
+			if kind := reflect.ValueOf(dstStr).Kind(); kind != md.ExpectedType {
+				return t.fail(path, NewInternalError(
+					fmt.Sprintf("translation func for %s returned %s, expected %s",
+						attr, kind, md.ExpectedType)))
+			}
+		}
+		// Save destination in the specified string
+		result[md.TargetName] = dstStr
+	case MapTranslation:
+		// nil (JSON null) is an absent optional object
+		if value == nil {
+			if !md.NilAsEmpty {
+				if !md.OmitEmpty {
+					result[md.TargetName] = nil
+				}
+				return nil
+			}
+			value = map[string]interface{}{}
+		}
+		// value should have type map[string]interface{}
+		srcMap, ok := value.(map[string]interface{})
+		if !ok {
+			return t.typeMismatch(attr, path, value, NewInternalError(
+				fmt.Sprintf("invalid type for %v: %T",
+					value, value)))
+		}
+		// Translate value according to SubTranslation
+		nErrs := len(t.errs)
+		trans, err := t.translate(srcMap, md.SubTranslation, path)
+		if err != nil {
+			return err
+		}
+		if len(t.errs) > nErrs {
+			// Nested errors are already collected
+			return nil
+		}
+		if md.OmitEmpty && len(trans) == 0 {
+			return nil
+		}
+		result[md.TargetName] = trans
+	case MapArrayTranslation:
+		if md.PassNonMaps {
+			return t.translateMixedField(result, attr, path, value, md)
+		}
+		// Translate [ {... }, {...} ]
+		srcMaps := []map[string]interface{}{}
+		err := mapstructure.Decode(value, &srcMaps)
+		if err != nil {
+			return t.typeMismatch(attr, path, value,
+				NewInternalError(err.Error()))
+		}
+		// Translate each value and combine results
+		nErrs := len(t.errs)
+		res, errs := t.translateElements(srcMaps, md.SubTranslation,
+			md.SkipInvalid, path)
+		if !md.SkipInvalid && len(errs) > 0 {
+			return errs[0].Err
+		}
+		if len(t.errs) > nErrs {
+			// Nested errors are already collected
+			return nil
+		}
+		result[md.TargetName] = res
+	case ModifyTranslation:
+		// Modify result based on value. Shoud have ModFunc.
+		if md.ModFunc == nil {
+			return t.fail(path,
+				NewInternalError("missing translation func for "+attr))
+		}
+		if t.guardModify {
+			return t.guardedModify(src, result, attr, path, value, md)
+		}
+		err := md.ModFunc(src, result, value)
+		if err != nil {
+			return t.fail(path, NewInvalidProp(attr, err.Error()))
+		}
+	case InsertTranslation:
+		// InsertTranslation is only used for missing fields
+	case MapValueScalarTranslation:
+		// nil (JSON null) is an absent optional object
+		if value == nil {
+			if !md.NilAsEmpty {
+				if !md.OmitEmpty {
+					result[md.TargetName] = nil
+				}
+				return nil
+			}
+			value = map[string]interface{}{}
+		}
+		// value should have type map[string]interface{}
+		srcMap, ok := value.(map[string]interface{})
+		if !ok {
+			return t.typeMismatch(attr, path, value, NewInternalError(
+				fmt.Sprintf("invalid type for %v: %T",
+					value, value)))
+		}
+		if md.MapFunc == nil {
+			return t.fail(path,
+				NewInternalError("missing translation func for "+attr))
+		}
+		nErrs := len(t.errs)
+		trans := make(map[string]interface{}, len(srcMap))
+		for _, k := range orderedKeys(srcMap, nil) {
+			val, err := md.MapFunc(srcMap[k])
+			if errors.Is(err, ErrOmit) {
+				continue
+			}
+			if err != nil {
+				err = t.fail(fieldPath(path, k), NewInvalidProp(k, err.Error()))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			trans[k] = val
+		}
+		if len(t.errs) > nErrs {
+			// Errors are already collected
+			return nil
+		}
+		result[md.TargetName] = trans
+	case IgnoreTranslation:
+		// The field is intentionally dropped
+	default:
+		return t.fail(path, NewInternalError("Invalid Translation type"))
+	}
+	return nil
+}
+
+// TranslateArray translates an array of objects using the same description
+// for each element. The src should be convertible to a slice of
+// map[string]interface{}.
+//
+// If skipInvalid is false, translation stops at the first invalid element and
+// its failure is returned as *ElementError. Otherwise invalid elements are
+// skipped: the result contains only valid translated elements and the list of
+// errors describes every skipped element.
+func TranslateArray(src interface{}, description map[string]interface{},
+	skipInvalid bool) ([]map[string]interface{}, []*ElementError, error) {
+	srcMaps := []map[string]interface{}{}
+	if err := mapstructure.Decode(src, &srcMaps); err != nil {
+		return nil, nil, NewInternalError(err.Error())
+	}
+	res, errs := (&translator{}).translateElements(srcMaps, description,
+		skipInvalid, "")
+	if !skipInvalid && len(errs) > 0 {
+		return nil, nil, errs[0]
+	}
+	return res, errs, nil
+}
+
+// translateElements translates each element of srcMaps. When skipInvalid is
+// false it stops at the first failure, otherwise failed elements are left out
+// of the result. Failures are reported with the index of the source element.
+// Elements that are skipped never contribute to the collected errors.
+func (t *translator) translateElements(srcMaps []map[string]interface{},
+	description map[string]interface{}, skipInvalid bool,
+	path string) ([]map[string]interface{}, []*ElementError) {
+	elemTranslator := t
+	if skipInvalid {
+		// Each invalid element is dropped as a whole, so translate elements
+		// in the fail-fast mode.
+		elemTranslator = t.failFast()
+	}
+	var errs []*ElementError
+	res := make([]map[string]interface{}, 0, len(srcMaps))
+	for i, val := range srcMaps {
+		nErrs := len(t.errs)
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		trans, err := elemTranslator.translate(val, description, elemPath)
+		if err != nil {
+			errs = append(errs, NewElementError(i, err))
+			if !skipInvalid {
+				return nil, errs
+			}
+			t.warnSkipped(elemPath, err)
+			continue
+		}
+		if len(t.errs) > nErrs {
+			// Errors are collected by the translator
+			continue
+		}
+		res = append(res, trans)
+	}
+	return res, errs
+}
+
+// callCtxMapFunc calls the CtxMapFunc of the description, limiting its run
+// time by Timeout if set. A timeout is reported as an error wrapping
+// context.DeadlineExceeded.
+func (t *translator) callCtxMapFunc(attr string, value interface{},
+	md Description) (interface{}, error) {
+	if md.Timeout <= 0 {
+		return md.CtxMapFunc(t.context(), value)
+	}
+	ctx, cancel := context.WithTimeout(t.context(), md.Timeout)
+	defer cancel()
+	res, err := md.CtxMapFunc(ctx, value)
+	if err != nil && ctx.Err() == context.DeadlineExceeded &&
+		t.context().Err() == nil {
+		return nil, fmt.Errorf("translation of %s timed out after %v: %w",
+			attr, md.Timeout, context.DeadlineExceeded)
+	}
+	return res, err
+}
+
+// typeMismatch handles a source value which has a wrong type for its
+// translation, e.g. a string for MapTranslation. Depending on the mode it
+// fails with internalErr or InvalidPropertyError, or returns ErrOmit to skip
+// the field.
+func (t *translator) typeMismatch(attr string, path string,
+	value interface{}, internalErr error) error {
+	switch t.typeMode {
+	case TypeMismatchInvalid:
+		return t.fail(path, NewInvalidProp(attr,
+			fmt.Sprintf("invalid type %T", value)))
+	case TypeMismatchSkip:
+		return ErrOmit
+	}
+	return t.fail(path, internalErr)
+}
+
+// translateMixedField translates an array with both maps and other values.
+// Maps are translated using SubTranslation and other values are copied as is,
+// so the result is []interface{}.
+func (t *translator) translateMixedField(result map[string]interface{},
+	attr string, path string, value interface{}, md Description) error {
+	arr, err := toSlice(value)
+	if err != nil {
+		return t.typeMismatch(attr, path, value,
+			NewInternalError(err.Error()))
+	}
+	elemTranslator := t
+	if md.SkipInvalid {
+		elemTranslator = t.failFast()
+	}
+	nErrs := len(t.errs)
+	res := make([]interface{}, 0, len(arr))
+	for i, val := range arr {
+		srcMap, ok := val.(map[string]interface{})
+		if !ok {
+			res = append(res, val)
+			continue
+		}
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		trans, err := elemTranslator.translate(srcMap, md.SubTranslation,
+			elemPath)
+		if err != nil {
+			if md.SkipInvalid {
+				t.warnSkipped(elemPath, err)
+				continue
+			}
+			return err
+		}
+		res = append(res, trans)
+	}
+	if len(t.errs) > nErrs {
+		// Nested errors are already collected
+		return nil
+	}
+	result[md.TargetName] = res
+	return nil
+}
+
+// failFast returns a copy of the translator which doesn't collect errors
+func (t *translator) failFast() *translator {
+	ft := *t
+	ft.collect = false
+	ft.errs = nil
+	return &ft
+}
+
+// FieldEquals returns a SourcePredicate which is true when the source field
+// name is present and deeply equal to value, so values such as arrays are
+// compared by contents. It is useful for conditionally mandatory fields, e.g.
+//
+//	"reason": Description{
+//		MapFunc:     StringMap,
+//		MandatoryIf: FieldEquals("status", "rejected"),
+//	}
+func FieldEquals(name string, value interface{}) SourcePredicate {
+	return func(src map[string]interface{}) bool {
+		val, isPresent := src[name]
+		return isPresent && reflect.DeepEqual(val, value)
+	}
+}
+
 // IDMap translates an object to itself. This is the easiest way to deal with
 // embedded objects.
 func IDMap(src interface{}) (interface{}, error) {
 	return src, nil
 }
 
+// NewConditionalDropMap returns a MapFunc that drops the field (by returning
+// ErrOmit) when shouldDrop returns true for its value, e.g. for placeholder
+// values such as "N/A". Otherwise the value is translated using inner.
+func NewConditionalDropMap(shouldDrop func(interface{}) bool,
+	inner MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		if shouldDrop(src) {
+			return nil, ErrOmit
+		}
+		return inner(src)
+	}
+}
+
+// NewUnwrapMap returns a MapFunc for scalars wrapped in an object such as
+// {"value": "x"}. It extracts the value under key and translates it using
+// inner (if not nil). An object without the key is rejected.
+func NewUnwrapMap(key string, inner MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcMap, ok := src.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v is not an object", src)
+		}
+		val, isPresent := srcMap[key]
+		if !isPresent {
+			return nil, NewMissingAttributeError(key)
+		}
+		if inner == nil {
+			return val, nil
+		}
+		return inner(val)
+	}
+}
+
+// NewExpandMap returns a MapFunc that is the reverse of NewUnwrapMap: it
+// expands a scalar value into an object using expand. For example, "5" may
+// become {"amount": "5", "currency": "USD"}. A nil object returned by expand
+// is treated as an error.
+func NewExpandMap(expand func(interface{}) (map[string]interface{}, error)) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		obj, err := expand(src)
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			return nil, fmt.Errorf("no object for value %v", src)
+		}
+		return obj, nil
+	}
+}
+
+// NewValidateMap returns a MapFunc that validates the value using predicate
+// and returns the original value unchanged if predicate returns nil.
+func NewValidateMap(predicate func(interface{}) error) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		if err := predicate(src); err != nil {
+			return nil, err
+		}
+		return src, nil
+	}
+}
+
 // StringMap translates string interface into a string (trimming spaces)
 func StringMap(src interface{}) (interface{}, error) {
 	if srcStr, ok := src.(string); ok {
@@ -318,6 +1515,35 @@ func StringMap(src interface{}) (interface{}, error) {
 	return "", fmt.Errorf("invalid type %T for %v", src, src)
 }
 
+// NonEmptyStringMap is like StringMap but rejects strings which are empty
+// after trimming. Unlike Mandatory, which only checks that the field is
+// present, it catches blank values.
+func NonEmptyStringMap(src interface{}) (interface{}, error) {
+	res, err := StringMap(src)
+	if err != nil {
+		return "", err
+	}
+	if res == "" {
+		return "", errors.New("value is empty")
+	}
+	return res, nil
+}
+
+// NewStringMap returns a MapFunc that translates string interface into a
+// string. Leading and trailing spaces are trimmed only if trim is true.
+// NewStringMap(true) is equivalent to StringMap.
+func NewStringMap(trim bool) MapFunc {
+	if trim {
+		return StringMap
+	}
+	return func(src interface{}) (interface{}, error) {
+		if srcStr, ok := src.(string); ok {
+			return srcStr, nil
+		}
+		return "", fmt.Errorf("invalid type %T for %v", src, src)
+	}
+}
+
 // StringToLowerMap translates string interface into a string with lower case
 func StringToLowerMap(src interface{}) (interface{}, error) {
 	if srcStr, ok := src.(string); ok {
@@ -362,6 +1588,25 @@ func IPAddrMap(src interface{}) (interface{}, error) {
 	return srcStr, nil
 }
 
+// HostnameMap verifies that the argument is a valid hostname according to
+// RFC 1123 and returns it in lower case. A trailing dot is removed.
+func HostnameMap(src interface{}) (interface{}, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		return "", fmt.Errorf("%v is not a string", src)
+	}
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(srcStr)), ".")
+	if name == "" || len(name) > 253 {
+		return "", fmt.Errorf("%s is not a valid hostname", srcStr)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !validHostLabel.MatchString(label) {
+			return "", fmt.Errorf("%s is not a valid hostname", srcStr)
+		}
+	}
+	return name, nil
+}
+
 // CIDRMap verifies that the argument is a valid IP address in CIDR notation
 // notation
 func CIDRMap(src interface{}) (interface{}, error) {
@@ -376,6 +1621,28 @@ func CIDRMap(src interface{}) (interface{}, error) {
 	return "", fmt.Errorf("%s is not a valid CIDR address", srcStr)
 }
 
+// NewCIDRMap returns a MapFunc that verifies that the argument is a valid IP
+// address in CIDR notation. If canonical is true, the result is the masked
+// network address, e.g. "1.2.3.4/24" becomes "1.2.3.0/24". Otherwise it
+// behaves as CIDRMap.
+func NewCIDRMap(canonical bool) MapFunc {
+	if !canonical {
+		return CIDRMap
+	}
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		_, network, err := net.ParseCIDR(srcStr)
+		if err != nil {
+			return "", fmt.Errorf("%s is not a valid CIDR address", srcStr)
+		}
+		return network.String(), nil
+	}
+}
+
 // BoolMap translates boolean interface into a boolean
 func BoolMap(src interface{}) (interface{}, error) {
 	val, ok := src.(bool)
@@ -405,7 +1672,7 @@ func BoolToStrMap(src interface{}) (interface{}, error) {
 	return "False", nil
 }
 
-// IntegerMap Converts numbers to strings
+// IntegerMap Converts numbers to strings. Non-integral numbers are rejected.
 func IntegerMap(val interface{}) (interface{}, error) {
 	switch val := val.(type) {
 	case int:
@@ -429,12 +1696,37 @@ func IntegerMap(val interface{}) (interface{}, error) {
 		if val < 0 {
 			return "", fmt.Errorf("%v should be non-negative", val)
 		}
+		// Only integral values such as 2.0 are accepted
+		if val != math.Trunc(val) || val >= math.MaxUint64 {
+			return "", fmt.Errorf("invalid value '%v' for an integer", val)
+		}
 		i := uint64(val)
 		return strconv.FormatUint(i, 10), nil // convert to string
+	case json.Number:
+		if i, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return strconv.FormatUint(i, 10), nil
+		}
+		// Accept integral values such as "2.0" or "1e3" following the float64
+		// rules without losing precision or range
+		f, err := val.Float64()
+		if err != nil {
+			return "", fmt.Errorf("invalid value '%s' for an integer", val)
+		}
+		return IntegerMap(f)
 	}
 	return nil, fmt.Errorf("invalid type %t for value %v", val, val)
 }
 
+// StrictIntegerMap is similar to IntegerMap but rejects strings with leading
+// zeros, such as "007", instead of normalizing them. A single "0" is valid.
+func StrictIntegerMap(val interface{}) (interface{}, error) {
+	if str, ok := val.(string); ok && len(str) > 1 && str[0] == '0' {
+		return "", fmt.Errorf("invalid value '%s' for an integer: leading zeros",
+			str)
+	}
+	return IntegerMap(val)
+}
+
 // UUIDMap translates UUID values and verifies that they are legal
 func UUIDMap(src interface{}) (interface{}, error) {
 	srcStr, ok := src.(string)
@@ -461,13 +1753,17 @@ func StringArrayMap(src interface{}) (interface{}, error) {
 }
 
 // IsSimilar verifies that dst object matches src object according to
-// description
+// description. If a Description has CompareFunc set, it is used to compare
+// the source value with the destination value regardless of the translation
+// type. Source fields without their own entry are compared according to the
+// matching Pattern entry, if any.
 func IsSimilar(src map[string]interface{}, dst map[string]interface{},
 	descr map[string]interface{}) (bool, error) {
 
+	patterns := descriptionPatterns(descr)
 	for k, vSrc := range src {
-		mapDescr, ok := descr[k]
-		if !ok {
+		mapDescr, ok := resolveDescription(descr, patterns, k)
+		if !ok || mapDescr == nil {
 			continue
 		}
 
@@ -500,7 +1796,31 @@ func IsSimilar(src map[string]interface{}, dst map[string]interface{},
 			return false, NewInternalError(
 				fmt.Sprintf("invalid description %v", mapDescr))
 		}
+		if md.CompareFunc != nil {
+			targetName := md.TargetName
+			if targetName == "" {
+				targetName = k
+			}
+			vDst, ok := dst[targetName]
+			if !ok {
+				return false,
+					fmt.Errorf("Missing value for %s in %v",
+						targetName, dst)
+			}
+			r, err := md.CompareFunc(vSrc, vDst)
+			if err != nil {
+				return false, err
+			}
+			if !r {
+				return false,
+					fmt.Errorf("Values %v and %v don't match",
+						vSrc, vDst)
+			}
+			continue
+		}
 		switch md.Type {
+		case IgnoreTranslation:
+			continue
 		case MapTranslation:
 			srcMap, ok := vSrc.(map[string]interface{})
 			if !ok {
@@ -1,8 +1,16 @@
 package maptrans
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -570,3 +578,1123 @@ func TestInvalidUUID(t *testing.T) {
 	_, err := Translate(src, descr)
 	assert.Error(t, err, "Error expected")
 }
+
+func TestMapArraySkipInvalid(t *testing.T) {
+	t.Parallel()
+	sub := map[string]interface{}{
+		"A": Description{TargetName: "a",
+			MapFunc:   IntegerMap,
+			Mandatory: true,
+		},
+	}
+	descr := map[string]interface{}{
+		"M": Description{TargetName: "m",
+			Type:           MapArrayTranslation,
+			SubTranslation: sub,
+		},
+	}
+	src := map[string]interface{}{
+		"M": []map[string]interface{}{
+			{"A": "1"},
+			{"A": "foo"},
+			{"B": "2"},
+			{"A": 3},
+		},
+	}
+	_, err := Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+
+	md := descr["M"].(Description)
+	md.SkipInvalid = true
+	descr["M"] = md
+	dst, err := Translate(src, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []map[string]interface{}{{"a": "1"}, {"a": "3"}},
+		dst["m"])
+
+	// Errors of skipped elements are reported as warnings and in stats
+	var warnings []string
+	stats := TranslateStats{}
+	_, err = TranslateWithOptions(src, descr, WithWarnings(&warnings),
+		WithStats(&stats), WithCollectErrors())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"element 'M[1]' skipped: property 'A' is invalid: " +
+			"invalid value 'foo' for an integer",
+		"element 'M[2]' skipped: missing mandatory attribute 'A'",
+	}, warnings)
+	assert.Equal(t, 2, stats.Errors)
+
+	res, errs, err := TranslateArray(src["M"], sub, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, res, 2)
+	if !assert.Len(t, errs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, errs[0].Index)
+	assert.Equal(t, 2, errs[1].Index)
+	assert.IsType(t, &MissingAttributeError{}, errs[1].Err)
+
+	_, _, err = TranslateArray(src["M"], sub, false)
+	if assert.Error(t, err) {
+		assert.Equal(t, 1, err.(*ElementError).Index)
+	}
+}
+
+func TestTranslateAll(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"id": Description{TargetName: "ID",
+			MapFunc:   UUIDMap,
+			Mandatory: true,
+		},
+		"count": Description{MapFunc: IntegerMap},
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": Description{MapFunc: IPAddrMap},
+			},
+		},
+		"routes": Description{
+			Type: MapArrayTranslation,
+			SubTranslation: map[string]interface{}{
+				"gw": Description{MapFunc: IPAddrMap, Mandatory: true},
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name":  "foo",
+		"count": "x",
+		"info":  map[string]interface{}{"ip": "bad"},
+		"routes": []map[string]interface{}{
+			{"gw": "1.2.3.4"},
+			{"gw": "bad"},
+			{},
+		},
+	}
+	for i := 0; i < 10; i++ {
+		dst, err := TranslateAll(src, descr)
+		assert.Nil(t, dst)
+		errs, ok := err.(TranslationErrors)
+		if !assert.True(t, ok) {
+			t.FailNow()
+		}
+		paths := []string{}
+		for _, e := range errs {
+			paths = append(paths, e.Path)
+		}
+		assert.Equal(t, []string{"count", "id", "info.ip",
+			"routes[1].gw", "routes[2].gw"}, paths)
+		assert.IsType(t, &MissingAttributeError{}, errs[1].Err)
+	}
+
+	// Array indices are ordered numerically
+	routes := make([]interface{}, 11)
+	for i := range routes {
+		routes[i] = map[string]interface{}{"gw": "1.2.3.4"}
+	}
+	routes[2] = map[string]interface{}{"gw": "bad"}
+	routes[10] = map[string]interface{}{"gw": "bad"}
+	_, err := TranslateAll(map[string]interface{}{
+		"id":     "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"routes": routes,
+	}, descr)
+	if errs, ok := err.(TranslationErrors); assert.True(t, ok) &&
+		assert.Len(t, errs, 2) {
+		assert.Equal(t, "routes[2].gw", errs[0].Path)
+		assert.Equal(t, "routes[10].gw", errs[1].Path)
+	}
+
+	src = map[string]interface{}{
+		"id":    "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"count": 1,
+	}
+	dst, err := TranslateAll(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ID":    "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"count": "1",
+	}, dst)
+}
+
+func TestNewStringMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewStringMap(false)("  code\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "  code\n", res)
+	res, err = NewStringMap(true)("  code\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "code", res)
+	_, err = NewStringMap(false)(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestMandatoryIf(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"status": "Status",
+		"reason": Description{TargetName: "Reason",
+			MapFunc:     StringMap,
+			MandatoryIf: FieldEquals("status", "rejected"),
+		},
+	}
+	_, err := Translate(map[string]interface{}{"status": "rejected"}, descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &MissingAttributeError{}, err)
+	}
+	dst, err := Translate(map[string]interface{}{"status": "rejected",
+		"reason": "spam"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, "spam", dst["Reason"])
+	_, err = Translate(map[string]interface{}{"status": "accepted"}, descr)
+	assert.NoError(t, err)
+	// Uncomparable values don't panic
+	assert.False(t, FieldEquals("status", "rejected")(
+		map[string]interface{}{"status": []interface{}{"rejected"}}))
+	assert.True(t, FieldEquals("tags", []interface{}{"a"})(
+		map[string]interface{}{"tags": []interface{}{"a"}}))
+}
+
+func TestTranslateMerge(t *testing.T) {
+	t.Parallel()
+	d1 := map[string]interface{}{"name": "Name"}
+	d2 := map[string]interface{}{
+		"id": Description{TargetName: "ID", MapFunc: IntegerMap},
+	}
+	src := map[string]interface{}{"name": "foo", "id": 1}
+	dst, err := TranslateMerge(src, d1, d2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo", "ID": "1"}, dst)
+
+	d3 := map[string]interface{}{"id": "Name"}
+	_, err = TranslateMerge(map[string]interface{}{"name": "foo",
+		"id": "1"}, d1, d3)
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &InternalError{}, err)
+	}
+	_, err = TranslateMerge(map[string]interface{}{"id": "x"}, d1, d2)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestTranslateStrict(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":     "Name",
+		"internal": Ignore,
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": Description{MapFunc: IPAddrMap},
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name":     "foo",
+		"internal": "secret",
+		"info":     map[string]interface{}{"ip": "1.2.3.4"},
+	}
+	dst, err := TranslateStrict(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name": "foo",
+		"info": map[string]interface{}{"ip": "1.2.3.4"},
+	}, dst)
+
+	dst, err = Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Nil(t, dst["internal"])
+
+	src["extra"] = 1
+	_, err = TranslateStrict(src, descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &UnknownAttributeError{}, err)
+	}
+	delete(src, "extra")
+	src["info"] = map[string]interface{}{"ip": "1.2.3.4", "mask": "24"}
+	_, err = TranslateStrict(src, descr)
+	assert.Error(t, err, "Error expected")
+}
+
+type storeKey struct{}
+
+func TestTranslateContext(t *testing.T) {
+	t.Parallel()
+	exists := func(ctx context.Context, v interface{}) (interface{}, error) {
+		store, _ := ctx.Value(storeKey{}).(map[string]bool)
+		id, _ := v.(string)
+		if !store[id] {
+			return nil, fmt.Errorf("%v doesn't exist", v)
+		}
+		return id, nil
+	}
+	descr := map[string]interface{}{
+		"name": "Name",
+		"ref":  Description{TargetName: "Ref", CtxMapFunc: exists},
+	}
+	ctx := context.WithValue(context.Background(), storeKey{},
+		map[string]bool{"a": true})
+	dst, err := TranslateContext(ctx,
+		map[string]interface{}{"name": "foo", "ref": "a"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dst["Ref"])
+	_, err = TranslateContext(ctx,
+		map[string]interface{}{"ref": "b"}, descr)
+	assert.Error(t, err, "Error expected")
+	_, err = Translate(map[string]interface{}{"ref": "a"}, descr)
+	assert.Error(t, err, "Error expected")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = TranslateContext(canceled,
+		map[string]interface{}{"ref": "a"}, descr)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestNewCIDRMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewCIDRMap(true)(" 1.2.3.4/24")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.0/24", res)
+	res, err = NewCIDRMap(true)("2001:db8::1/32")
+	assert.NoError(t, err)
+	assert.Equal(t, "2001:db8::/32", res)
+	res, err = NewCIDRMap(false)("1.2.3.4/24")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.4/24", res)
+	_, err = NewCIDRMap(true)("1.2.3.4")
+	assert.Error(t, err, "Error expected")
+	_, err = NewCIDRMap(true)(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestTranslateWithKeyFunc(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"host": "Host",
+		"meta": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"id": Description{MapFunc: IntegerMap, Mandatory: true},
+			},
+		},
+	}
+	stripPrefix := func(k string) string {
+		return strings.TrimPrefix(strings.ToLower(k), "x-")
+	}
+	src := map[string]interface{}{
+		"X-Host": "example.com",
+		"x-meta": map[string]interface{}{"X-ID": 5},
+		"Other":  "dropped",
+	}
+	dst, err := TranslateWithKeyFunc(src, descr, stripPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Host": "example.com",
+		"meta": map[string]interface{}{"id": "5"},
+	}, dst)
+
+	src["host"] = "other.com"
+	_, err = TranslateWithKeyFunc(src, descr, stripPrefix)
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &InvalidPropertyError{}, err)
+	}
+
+	// The same duplicate is reported every time
+	for i := 0; i < 10; i++ {
+		_, err = TranslateWithOptions(map[string]interface{}{"A": 1, "a": 2,
+			"B": 3, "b": 4}, descr, WithKeyFunc(strings.ToLower),
+			WithCollectErrors())
+		assert.Equal(t, map[string]string{
+			"a": "duplicate key 'a'",
+			"b": "duplicate key 'b'",
+		}, TranslateErrorsToMap(err))
+	}
+}
+
+func TestTranslateWithStats(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":     "Name",
+		"internal": Ignore,
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip":   Description{MapFunc: IPAddrMap},
+				"port": Description{MapFunc: IntegerMap},
+			},
+		},
+		"kind": Description{
+			Type:       InsertTranslation,
+			TargetName: "kind",
+			InsertFunc: func(map[string]interface{}, map[string]interface{},
+				string) (interface{}, error) {
+				return "host", nil
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name":     "foo",
+		"internal": "secret",
+		"extra":    1,
+		"info":     map[string]interface{}{"ip": "1.2.3.4", "port": 80},
+	}
+	_, stats, err := TranslateWithStats(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, TranslateStats{Translated: 4, Dropped: 2, Inserted: 1},
+		stats)
+
+	src["info"] = map[string]interface{}{"ip": "bad"}
+	_, stats, err = TranslateWithStats(src, descr)
+	assert.Error(t, err, "Error expected")
+	assert.Equal(t, 1, stats.Errors)
+}
+
+func TestExpectedType(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"flag": Description{MapFunc: BoolMap, ExpectedType: reflect.Bool},
+		"id":   Description{MapFunc: IntegerMap, ExpectedType: reflect.Int},
+	}
+	dst, err := Translate(map[string]interface{}{"flag": "true"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, true, dst["flag"])
+	_, err = Translate(map[string]interface{}{"id": 1}, descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &InternalError{}, err)
+		assert.Contains(t, err.Error(), "returned string, expected int")
+	}
+}
+
+func TestNilDescription(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":  "Name",
+		"other": nil,
+	}
+	src := map[string]interface{}{"name": "foo", "other": "bar"}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst)
+	_, err = TranslateStrict(src, descr)
+	assert.NoError(t, err)
+	dst, err = Translate(map[string]interface{}{"name": "foo"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst)
+	r, err := IsSimilar(src, map[string]interface{}{"Name": "foo"}, descr)
+	assert.NoError(t, err)
+	assert.True(t, r)
+}
+
+func TestTranslateErrorsToMap(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"email": Description{MapFunc: IdentifierMap, Mandatory: true},
+		"age":   Description{MapFunc: IntegerMap},
+	}
+	_, err := TranslateAll(map[string]interface{}{"age": -1}, descr)
+	assert.Equal(t, map[string]string{
+		"email": "missing mandatory attribute 'email'",
+		"age":   "-1 should be non-negative",
+	}, TranslateErrorsToMap(err))
+	assert.Nil(t, TranslateErrorsToMap(nil))
+	_, err = Translate(map[string]interface{}{"age": -1}, descr)
+	assert.Len(t, TranslateErrorsToMap(err), 1)
+
+	// Errors for the same path are joined
+	err = TranslationErrors{
+		NewFieldError("a", NewInvalidProp("a", "too long")),
+		NewFieldError("a", fmt.Errorf("bad")),
+		NewFieldError("b", fmt.Errorf("worse")),
+	}
+	assert.Equal(t, map[string]string{
+		"a": "too long; bad",
+		"b": "worse",
+	}, TranslateErrorsToMap(err))
+}
+
+func TestMapValueScalarTranslation(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"limits": Description{
+			TargetName: "Limits",
+			Type:       MapValueScalarTranslation,
+			MapFunc:    IntegerMap,
+		},
+	}
+	src := map[string]interface{}{
+		"limits": map[string]interface{}{"cpu": 2, "memory": "1024"},
+	}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"cpu": "2", "memory": "1024"},
+		dst["Limits"])
+
+	src["limits"] = map[string]interface{}{"cpu": "x", "memory": -1}
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+	_, err = TranslateAll(src, descr)
+	assert.Equal(t, map[string]string{
+		"limits.cpu":    "invalid value 'x' for an integer",
+		"limits.memory": "-1 should be non-negative",
+	}, TranslateErrorsToMap(err))
+
+	src["limits"] = "cpu"
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+
+	// ErrOmit leaves the entry out
+	descr["limits"] = Description{
+		TargetName: "Limits",
+		Type:       MapValueScalarTranslation,
+		MapFunc: func(v interface{}) (interface{}, error) {
+			if v == nil {
+				return nil, ErrOmit
+			}
+			return IntegerMap(v)
+		},
+	}
+	src["limits"] = map[string]interface{}{"cpu": 2, "memory": nil}
+	dst, err = Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"cpu": "2"}, dst["Limits"])
+
+	// nil is an absent optional object
+	src["limits"] = nil
+	dst, err = Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Limits": nil}, dst)
+}
+
+func TestMapValueScalarTranslationOrder(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"limits": Description{
+			TargetName: "Limits",
+			Type:       MapValueScalarTranslation,
+			MapFunc:    IntegerMap,
+		},
+	}
+	src := map[string]interface{}{
+		"limits": map[string]interface{}{
+			"d": "x", "c": "x", "b": "x", "a": "x",
+		},
+	}
+	for i := 0; i < 50; i++ {
+		_, err := Translate(src, descr)
+		if assert.IsType(t, &InvalidPropertyError{}, err) {
+			assert.Equal(t, "a", err.(*InvalidPropertyError).Name)
+		}
+	}
+}
+
+func TestMapTranslationNil(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"info": Description{
+			TargetName: "Info",
+			Type:       MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": "IP",
+			},
+		},
+	}
+	src := map[string]interface{}{"info": nil}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	v, isPresent := dst["Info"]
+	assert.True(t, isPresent)
+	assert.Nil(t, v)
+
+	md := descr["info"].(Description)
+	md.NilAsEmpty = true
+	descr["info"] = md
+	dst, err = Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, dst["Info"])
+}
+
+func TestValidateMap(t *testing.T) {
+	t.Parallel()
+	positive := NewValidateMap(func(v interface{}) error {
+		if n, ok := v.(float64); !ok || n <= 0 {
+			return fmt.Errorf("%v is not a positive number", v)
+		}
+		return nil
+	})
+	res, err := positive(1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, res)
+	_, err = positive(-1.0)
+	assert.Error(t, err, "Error expected")
+	_, err = positive("1")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestConcurrentTranslate(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"id":   Description{MapFunc: IntegerMap},
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": Description{MapFunc: IPAddrMap},
+			},
+		},
+		"routes": Description{
+			TargetName: "Routes",
+			Type:       MapArrayTranslation,
+			SubTranslation: map[string]interface{}{
+				"gw": Description{MapFunc: IPAddrMap},
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name":   "foo",
+		"id":     1,
+		"info":   map[string]interface{}{"ip": "1.2.3.4"},
+		"routes": []map[string]interface{}{{"gw": "1.2.3.1"}},
+	}
+	expected, err := Translate(src, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				dst, err := TranslateWithOptions(src, descr,
+					WithCollectErrors(), WithStrict())
+				assert.NoError(t, err)
+				assert.Equal(t, expected, dst)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMapTranslationOmitEmpty(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"info": Description{
+			TargetName: "Info",
+			Type:       MapTranslation,
+			Mandatory:  true,
+			OmitEmpty:  true,
+			SubTranslation: map[string]interface{}{
+				"ip": "IP",
+			},
+		},
+	}
+	for _, v := range []interface{}{
+		map[string]interface{}{"mask": "24"},
+		map[string]interface{}{},
+		nil,
+	} {
+		dst, err := Translate(map[string]interface{}{"info": v}, descr)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{}, dst)
+	}
+	dst, err := Translate(map[string]interface{}{
+		"info": map[string]interface{}{"ip": "1.2.3.4"},
+	}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"IP": "1.2.3.4"}, dst["Info"])
+	_, err = Translate(map[string]interface{}{}, descr)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestStrictInteger(t *testing.T) {
+	t.Parallel()
+	for _, v := range []interface{}{"7", "0", "70", 7} {
+		_, err := StrictIntegerMap(v)
+		assert.NoError(t, err)
+	}
+	res, err := StrictIntegerMap("70")
+	assert.NoError(t, err)
+	assert.Equal(t, "70", res)
+	for _, v := range []interface{}{"007", "00", "x", -1} {
+		_, err := StrictIntegerMap(v)
+		assert.Error(t, err, "Error expected for %v", v)
+	}
+}
+
+func TestConditionalDropMap(t *testing.T) {
+	t.Parallel()
+	notAvailable := func(v interface{}) bool { return v == "N/A" }
+	descr := map[string]interface{}{
+		"count": Description{
+			MapFunc: NewConditionalDropMap(notAvailable, IntegerMap),
+		},
+		"kind": Description{
+			Type:       InsertTranslation,
+			TargetName: "kind",
+			InsertFunc: func(map[string]interface{}, map[string]interface{},
+				string) (interface{}, error) {
+				return nil, ErrOmit
+			},
+		},
+	}
+	stats := TranslateStats{}
+	dst, err := TranslateWithOptions(map[string]interface{}{"count": "N/A"},
+		descr, WithStats(&stats))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, dst)
+	assert.Equal(t, TranslateStats{Dropped: 1}, stats)
+	dst, err = Translate(map[string]interface{}{"count": "2"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"count": "2"}, dst)
+	_, err = Translate(map[string]interface{}{"count": "x"}, descr)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestHostname(t *testing.T) {
+	t.Parallel()
+	for src, expected := range map[string]string{
+		"Example.COM":           "example.com",
+		" my-host.example.org.": "my-host.example.org",
+		"localhost":             "localhost",
+		"1.example.com":         "1.example.com",
+	} {
+		res, err := HostnameMap(src)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, res)
+	}
+	for _, bad := range []interface{}{"", "-host.com", "host-.com",
+		"a..b", "under_score.com", strings.Repeat("a", 64) + ".com",
+		strings.Repeat("abcdefghi.", 26) + "com", 1} {
+		_, err := HostnameMap(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestTranslateWithKeys(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":     "Name",
+		"internal": Ignore,
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": Description{MapFunc: IPAddrMap},
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name":     "foo",
+		"internal": 1,
+		"extra":    2,
+		"info":     map[string]interface{}{"ip": "1.2.3.4", "mask": "24"},
+	}
+	_, keys, err := TranslateWithKeys(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"info", "info.ip", "internal", "name"}, keys)
+}
+
+func TestUnwrapMap(t *testing.T) {
+	t.Parallel()
+	unwrap := NewUnwrapMap("value", IntegerMap)
+	res, err := unwrap(map[string]interface{}{"value": 5})
+	assert.NoError(t, err)
+	assert.Equal(t, "5", res)
+	_, err = unwrap(map[string]interface{}{"val": 5})
+	assert.Error(t, err, "Error expected")
+	_, err = unwrap(map[string]interface{}{"value": "x"})
+	assert.Error(t, err, "Error expected")
+	_, err = unwrap(5)
+	assert.Error(t, err, "Error expected")
+	res, err = NewUnwrapMap("value", nil)(map[string]interface{}{"value": 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, res)
+}
+
+func TestIntegerJSONNumber(t *testing.T) {
+	t.Parallel()
+	res, err := IntegerMap(json.Number("18446744073709551615"))
+	assert.NoError(t, err)
+	assert.Equal(t, "18446744073709551615", res)
+	res, err = IntegerMap(json.Number("2.0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", res)
+	res, err = IntegerMap(json.Number("1e3"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1000", res)
+	for _, v := range []json.Number{"-1", "2.5", "1e30", "x"} {
+		_, err = IntegerMap(v)
+		assert.Error(t, err, "Error expected for %v", v)
+	}
+
+	// float64 follows the same rules
+	res, err = IntegerMap(2.0)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", res)
+	for _, v := range []float64{-1, 2.5, 1e30} {
+		_, err = IntegerMap(v)
+		assert.Error(t, err, "Error expected for %v", v)
+	}
+}
+
+func TestExpandMap(t *testing.T) {
+	t.Parallel()
+	expand := NewExpandMap(func(v interface{}) (map[string]interface{}, error) {
+		amount, err := IntegerMap(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"amount": amount, "currency": "USD"}, nil
+	})
+	descr := map[string]interface{}{
+		"price": Description{TargetName: "Price", MapFunc: expand},
+	}
+	res, err := Translate(map[string]interface{}{"price": "5"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Price": map[string]interface{}{"amount": "5", "currency": "USD"},
+	}, res)
+	_, err = Translate(map[string]interface{}{"price": "x"}, descr)
+	assert.Error(t, err, "Error expected")
+
+	empty := NewExpandMap(func(interface{}) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	_, err = empty(5)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestRenameKeys(t *testing.T) {
+	t.Parallel()
+	src := map[string]interface{}{"a": 1, "b": 2, "c": 3}
+	renames := map[string]string{"a": "A", "c": "b"}
+	assert.Equal(t, map[string]interface{}{"A": 1, "b": 3},
+		RenameKeys(src, renames, true))
+	assert.Equal(t, map[string]interface{}{"A": 1, "b": 3},
+		RenameKeys(src, renames, false))
+	assert.Equal(t, map[string]interface{}{"A": 1, "b": 2},
+		RenameKeys(map[string]interface{}{"a": 1, "b": 2}, renames, true))
+	assert.Equal(t, map[string]interface{}{"A": 1},
+		RenameKeys(map[string]interface{}{"a": 1, "b": 2}, renames, false))
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2, "c": 3}, src)
+
+	// The lowest source key wins when several keys are renamed to one name
+	renames = map[string]string{"a": "x", "b": "x", "c": "x"}
+	for i := 0; i < 50; i++ {
+		assert.Equal(t, map[string]interface{}{"x": 1},
+			RenameKeys(src, renames, false))
+	}
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+	// The ModFunc of "a" needs the translated "z", so "z" goes first
+	descr := map[string]interface{}{
+		"a": Description{
+			Type: ModifyTranslation,
+			ModFunc: func(_, dst map[string]interface{}, v interface{}) error {
+				z, ok := dst["Z"].(string)
+				if !ok {
+					return fmt.Errorf("Z is not translated yet")
+				}
+				dst["A"] = z + v.(string)
+				return nil
+			},
+		},
+		"z": Description{TargetName: "Z", MapFunc: StringMap, Priority: -1},
+		"total": Description{
+			TargetName: "total",
+			Type:       InsertTranslation,
+			Priority:   1,
+			InsertFunc: func(_, dst map[string]interface{},
+				_ string) (interface{}, error) {
+				return len(dst), nil
+			},
+		},
+		"count": Description{
+			TargetName: "count",
+			Type:       InsertTranslation,
+			InsertFunc: func(_, dst map[string]interface{},
+				_ string) (interface{}, error) {
+				return len(dst), nil
+			},
+		},
+	}
+	for i := 0; i < 20; i++ {
+		dst, err := Translate(map[string]interface{}{"a": "b", "z": "y"},
+			descr)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, map[string]interface{}{
+			"A": "yb", "Z": "y", "count": 2, "total": 3}, dst)
+	}
+}
+
+func TestPriorityPattern(t *testing.T) {
+	t.Parallel()
+	// The ModFunc of "a" needs the translated "z_id", which is described by a
+	// prioritised Pattern entry
+	descr := map[string]interface{}{
+		"a": Description{
+			Type: ModifyTranslation,
+			ModFunc: func(_, dst map[string]interface{}, v interface{}) error {
+				z, ok := dst["Z_id"].(string)
+				if !ok {
+					return fmt.Errorf("Z_id is not translated yet")
+				}
+				dst["A"] = z + v.(string)
+				return nil
+			},
+		},
+		"z_*": Description{
+			TargetName: "Z_*",
+			Pattern:    true,
+			MapFunc:    StringMap,
+			Priority:   -1,
+		},
+	}
+	for i := 0; i < 20; i++ {
+		dst, err := Translate(map[string]interface{}{"a": "b", "z_id": "y"},
+			descr)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, map[string]interface{}{"A": "yb", "Z_id": "y"}, dst)
+	}
+}
+
+func TestPassNonMaps(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"items": Description{
+			TargetName:     "Items",
+			Type:           MapArrayTranslation,
+			PassNonMaps:    true,
+			SubTranslation: map[string]interface{}{"name": "Name"},
+		},
+	}
+	src := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"name": "foo"}, "bar", 1, nil,
+		},
+	}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Items": []interface{}{
+			map[string]interface{}{"Name": "foo"}, "bar", 1, nil,
+		},
+	}, dst)
+
+	src["items"] = []interface{}{"bar", map[string]interface{}{"name": 1}}
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+	_, err = TranslateAll(src, descr)
+	assert.Equal(t, map[string]string{
+		"items[1].name": "invalid type int for 1",
+	}, TranslateErrorsToMap(err))
+
+	md := descr["items"].(Description)
+	md.SkipInvalid = true
+	descr["items"] = md
+	dst, err = Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Items": []interface{}{"bar"}}, dst)
+
+	_, err = Translate(map[string]interface{}{"items": "bar"}, descr)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestPatternDescription(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"meta_id": "ID",
+		"meta_*": Description{
+			Pattern:    true,
+			TargetName: "*",
+			MapFunc:    StringToLowerMap,
+		},
+		"meta_x*": Description{
+			Pattern:    true,
+			TargetName: "X_*",
+			MapFunc:    StringMap,
+		},
+		"*_count": Description{Pattern: true, MapFunc: IntegerMap},
+	}
+	src := map[string]interface{}{
+		"meta_id":     " Foo ",
+		"meta_Color":  "RED",
+		"meta_xSize":  "Big",
+		"meta_":       "Empty",
+		"error_count": 3,
+		"other":       1,
+	}
+	dst, err := TranslateStrict(src, descr)
+	assert.Error(t, err, "Error expected")
+	delete(src, "other")
+	dst, err = TranslateStrict(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ID":          "Foo",
+		"Color":       "red",
+		"X_Size":      "Big",
+		"meta_":       "empty",
+		"error_count": "3",
+	}, dst)
+
+	_, err = Translate(map[string]interface{}{"meta_a": 1}, descr)
+	assert.Error(t, err, "Error expected")
+
+	// All matching fields would have the same target
+	_, err = Translate(map[string]interface{}{"tag_a": 1},
+		map[string]interface{}{
+			"tag_*": Description{Pattern: true, TargetName: "Tag"},
+		})
+	assert.IsType(t, &InternalError{}, err)
+
+	// IsSimilar uses pattern entries for fields without their own entry
+	descr = map[string]interface{}{
+		"meta_*": Description{
+			Pattern:     true,
+			TargetName:  "*",
+			CompareFunc: EqualFoldCompare,
+		},
+	}
+	ok, err := IsSimilar(map[string]interface{}{"meta_color": "red"},
+		map[string]interface{}{"color": "RED"}, descr)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = IsSimilar(map[string]interface{}{"meta_color": "red"},
+		map[string]interface{}{"color": "blue"}, descr)
+	assert.False(t, ok)
+}
+
+func TestNonEmptyStringMap(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": Description{MapFunc: NonEmptyStringMap, Mandatory: true},
+	}
+	dst, err := Translate(map[string]interface{}{"name": " foo "}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "foo"}, dst)
+	_, err = Translate(map[string]interface{}{"name": " \t"}, descr)
+	assert.IsType(t, &InvalidPropertyError{}, err)
+	_, err = Translate(map[string]interface{}{"name": 1}, descr)
+	assert.IsType(t, &InvalidPropertyError{}, err)
+}
+
+func TestDeepCopyDescription(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":   "Name",
+		"hidden": nil,
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"routes": Description{
+					Type:           MapArrayTranslation,
+					SubTranslation: map[string]interface{}{"gw": "GW"},
+				},
+			},
+		},
+	}
+	clone := DeepCopyDescription(descr)
+	assert.Equal(t, descr, clone)
+
+	info := clone["info"].(Description)
+	routes := info.SubTranslation["routes"].(Description)
+	routes.SubTranslation["gw"] = "Gateway"
+	routes.Mandatory = true
+	info.SubTranslation["routes"] = routes
+	clone["name"] = "FullName"
+
+	assert.Equal(t, "Name", descr["name"])
+	origRoutes := descr["info"].(Description).
+		SubTranslation["routes"].(Description)
+	assert.False(t, origRoutes.Mandatory)
+	assert.Equal(t, "GW", origRoutes.SubTranslation["gw"])
+	assert.Nil(t, DeepCopyDescription(nil))
+}
+
+func TestStatsFailures(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"id": Description{MapFunc: IntegerMap, Mandatory: true},
+		"routes": Description{
+			Type: MapArrayTranslation,
+			SubTranslation: map[string]interface{}{
+				"gw": Description{MapFunc: IPAddrMap},
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"routes": []interface{}{
+			map[string]interface{}{"gw": "bad"},
+			map[string]interface{}{"gw": "1.2.3.4"},
+			map[string]interface{}{"gw": "worse"},
+		},
+		"extra": 1,
+	}
+	var stats TranslateStats
+	_, err := TranslateWithOptions(src, descr, WithStats(&stats),
+		WithCollectErrors(), WithStrict())
+	assert.Error(t, err, "Error expected")
+	assert.Equal(t, 4, stats.Errors)
+	assert.Equal(t, map[FieldFailure]int{
+		{Path: "id", Category: "missing"}:          1,
+		{Path: "extra", Category: "unknown"}:       1,
+		{Path: "routes[].gw", Category: "invalid"}: 2,
+	}, stats.Failures)
+}
+
+func TestCtxMapFuncTimeout(t *testing.T) {
+	t.Parallel()
+	slow := func(ctx context.Context, v interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return v, nil
+		}
+	}
+	descr := map[string]interface{}{
+		"name": "Name",
+		"ref": Description{
+			CtxMapFunc: slow,
+			Timeout:    10 * time.Millisecond,
+		},
+	}
+	start := time.Now()
+	_, err := Translate(map[string]interface{}{"ref": "x"}, descr)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	if assert.IsType(t, &InvalidPropertyError{}, err) {
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Contains(t, err.Error(), "ref")
+	}
+	stats := TranslateStats{}
+	_, err = TranslateWithOptions(map[string]interface{}{"ref": "x"}, descr,
+		WithStats(&stats))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, map[FieldFailure]int{{Path: "ref", Category: "invalid"}: 1},
+		stats.Failures)
+	_, err = TranslateAll(map[string]interface{}{"ref": "x"}, descr)
+	assert.Equal(t, map[string]string{
+		"ref": "translation of ref timed out after 10ms: " +
+			"context deadline exceeded",
+	}, TranslateErrorsToMap(err))
+
+	descr["ref"] = Description{
+		CtxMapFunc: func(ctx context.Context, v interface{}) (interface{}, error) {
+			return v, nil
+		},
+		Timeout: time.Second,
+	}
+	dst, err := Translate(map[string]interface{}{"ref": "x"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ref": "x"}, dst)
+}
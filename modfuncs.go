@@ -0,0 +1,119 @@
+package maptrans
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewConcatMod returns a ModFunc that joins string values of the source fields
+// using sep as a separator and stores the result in the destination map under
+// targetName. Values are trimmed before joining. If skipMissing is true,
+// absent source fields are skipped, otherwise they cause a
+// MissingAttributeError.
+//
+// The returned ModFunc is intended for use with ModifyTranslation and ignores
+// the value of the field it is attached to.
+func NewConcatMod(targetName string, sep string, skipMissing bool,
+	sources ...string) ModFunc {
+	return func(src map[string]interface{}, dst map[string]interface{},
+		_ interface{}) error {
+		parts := make([]string, 0, len(sources))
+		for _, name := range sources {
+			val, isPresent := src[name]
+			if !isPresent {
+				if skipMissing {
+					continue
+				}
+				return NewMissingAttributeError(name)
+			}
+			str, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("invalid type %T for %s", val, name)
+			}
+			parts = append(parts, strings.TrimSpace(str))
+		}
+		dst[targetName] = strings.Join(parts, sep)
+		return nil
+	}
+}
+
+// Merge copies all keys from src into dst. If overwrite is false and some key
+// of src is already present in dst, an error is returned and dst is left
+// unchanged. Merge is useful for ModFuncs which flatten nested objects into
+// the parent.
+func Merge(dst map[string]interface{}, src map[string]interface{},
+	overwrite bool) error {
+	if !overwrite {
+		for k := range src {
+			if _, isPresent := dst[k]; isPresent {
+				return fmt.Errorf("key '%s' is already present", k)
+			}
+		}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return nil
+}
+
+// NewRequiredTogetherMod returns a ModFunc which verifies that either all or
+// none of the given fields are present in the source. It doesn't modify the
+// destination. The error names the fields that are missing.
+//
+// A ModifyTranslation only runs when the field it is attached to is present,
+// so the ModFunc has to be attached to every listed field. Use
+// NewRequiredTogetherInsert to check the whole source object at once.
+func NewRequiredTogetherMod(fields ...string) ModFunc {
+	return func(src map[string]interface{}, _ map[string]interface{},
+		_ interface{}) error {
+		return checkRequiredTogether(src, fields)
+	}
+}
+
+// checkRequiredTogether verifies that either all or none of fields are
+// present in src
+func checkRequiredTogether(src map[string]interface{}, fields []string) error {
+	var present, missing []string
+	for _, name := range fields {
+		if _, isPresent := src[name]; isPresent {
+			present = append(present, name)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(present) == 0 || len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s required together with %s",
+		strings.Join(missing, ", "), strings.Join(present, ", "))
+}
+
+// NewMutuallyExclusiveMod returns a ModFunc which verifies that at most one of
+// the given fields is present in the source. It doesn't modify the
+// destination. The error names the conflicting fields.
+//
+// Like NewRequiredTogetherMod, the ModFunc only runs when the field it is
+// attached to is present, so with more than two fields it has to be attached
+// to every listed field. Use NewMutuallyExclusiveInsert to check the whole
+// source object at once.
+func NewMutuallyExclusiveMod(fields ...string) ModFunc {
+	return func(src map[string]interface{}, _ map[string]interface{},
+		_ interface{}) error {
+		return checkMutuallyExclusive(src, fields)
+	}
+}
+
+// checkMutuallyExclusive verifies that at most one of fields is present in src
+func checkMutuallyExclusive(src map[string]interface{}, fields []string) error {
+	var present []string
+	for _, name := range fields {
+		if _, isPresent := src[name]; isPresent {
+			present = append(present, name)
+		}
+	}
+	if len(present) > 1 {
+		return fmt.Errorf("%s are mutually exclusive",
+			strings.Join(present, ", "))
+	}
+	return nil
+}
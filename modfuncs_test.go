@@ -0,0 +1,143 @@
+package maptrans
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcatMod(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"city": Description{
+			Type:    ModifyTranslation,
+			ModFunc: NewConcatMod("location", ", ", true, "city", "country"),
+		},
+	}
+	src := map[string]interface{}{"city": "Paris ", "country": "France"}
+	dst, err := Translate(src, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Paris, France", dst["location"])
+
+	dst, err = Translate(map[string]interface{}{"city": "Paris"}, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Paris", dst["location"])
+
+	descr["city"] = Description{
+		Type:    ModifyTranslation,
+		ModFunc: NewConcatMod("location", ", ", false, "city", "country"),
+	}
+	_, err = Translate(map[string]interface{}{"city": "Paris"}, descr)
+	assert.Error(t, err, "Error expected")
+	_, err = Translate(map[string]interface{}{"city": "Paris",
+		"country": 1}, descr)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	flatten := func(src, dst map[string]interface{}, v interface{}) error {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v is not an object", v)
+		}
+		return Merge(dst, obj, false)
+	}
+	descr := map[string]interface{}{
+		"name":  "name",
+		"value": Description{Type: ModifyTranslation, ModFunc: flatten},
+	}
+	dst, err := Translate(map[string]interface{}{
+		"value": map[string]interface{}{"fruit": "apple"},
+	}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"fruit": "apple"}, dst)
+
+	dst = map[string]interface{}{"a": 1, "b": 2}
+	err = Merge(dst, map[string]interface{}{"c": 3, "b": 4}, false)
+	assert.Error(t, err, "Error expected")
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, dst)
+	err = Merge(dst, map[string]interface{}{"c": 3, "b": 4}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 4, "c": 3}, dst)
+}
+
+func TestRequiredTogetherMod(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"start_date": Description{
+			Type:    ModifyTranslation,
+			ModFunc: NewRequiredTogetherMod("start_date", "end_date"),
+		},
+		"end_date": "EndDate",
+	}
+	_, err := Translate(map[string]interface{}{"start_date": "2020-01-01",
+		"end_date": "2020-02-01"}, descr)
+	assert.NoError(t, err)
+	_, err = Translate(map[string]interface{}{}, descr)
+	assert.NoError(t, err)
+	_, err = Translate(map[string]interface{}{"start_date": "2020-01-01"},
+		descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "end_date")
+	}
+	// The ModFunc doesn't run when the field it is attached to is missing
+	_, err = Translate(map[string]interface{}{"end_date": "2020-02-01"},
+		descr)
+	assert.NoError(t, err)
+	descr["end_date"] = Description{
+		Type:    ModifyTranslation,
+		ModFunc: NewRequiredTogetherMod("start_date", "end_date"),
+	}
+	_, err = Translate(map[string]interface{}{"end_date": "2020-02-01"},
+		descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "start_date")
+	}
+
+	mod := NewRequiredTogetherMod("a", "b", "c")
+	err = mod(map[string]interface{}{"b": 1}, nil, nil)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "a, c")
+	}
+}
+
+func TestMutuallyExclusiveMod(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"ip": Description{
+			Type:    ModifyTranslation,
+			ModFunc: NewMutuallyExclusiveMod("ip", "dhcp"),
+		},
+		"dhcp": Description{TargetName: "DHCP", MapFunc: BoolMap},
+	}
+	_, err := Translate(map[string]interface{}{"ip": "1.2.3.4"}, descr)
+	assert.NoError(t, err)
+	_, err = Translate(map[string]interface{}{"dhcp": true}, descr)
+	assert.NoError(t, err)
+	_, err = Translate(map[string]interface{}{"ip": "1.2.3.4",
+		"dhcp": true}, descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "ip, dhcp")
+	}
+
+	// With more fields the ModFunc has to be attached to each of them
+	mod := NewMutuallyExclusiveMod("a", "b", "c")
+	descr = map[string]interface{}{
+		"a": Description{Type: ModifyTranslation, ModFunc: mod},
+		"b": "B",
+		"c": "C",
+	}
+	_, err = Translate(map[string]interface{}{"b": "x", "c": "y"}, descr)
+	assert.NoError(t, err)
+	descr["b"] = Description{Type: ModifyTranslation, ModFunc: mod}
+	_, err = Translate(map[string]interface{}{"b": "x", "c": "y"}, descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "b, c")
+	}
+}
@@ -0,0 +1,452 @@
+package maptrans
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// toFloat64 converts a numeric value or a numeric string to float64
+func toFloat64(val interface{}) (float64, error) {
+	switch val := val.(type) {
+	case float64:
+		return val, nil
+	case float32:
+		return float64(val), nil
+	case int:
+		return float64(val), nil
+	case int8:
+		return float64(val), nil
+	case int16:
+		return float64(val), nil
+	case int32:
+		return float64(val), nil
+	case int64:
+		return float64(val), nil
+	case uint:
+		return float64(val), nil
+	case uint8:
+		return float64(val), nil
+	case uint16:
+		return float64(val), nil
+	case uint32:
+		return float64(val), nil
+	case uint64:
+		return float64(val), nil
+	case json.Number:
+		return val.Float64()
+	case string:
+		result, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value '%s' for a number", val)
+		}
+		return result, nil
+	}
+	return 0, fmt.Errorf("invalid type %T for value %v", val, val)
+}
+
+// toInt64 converts an integer value, an integral float or a numeric string to
+// int64
+func toInt64(val interface{}) (int64, error) {
+	switch val := val.(type) {
+	case int:
+		return int64(val), nil
+	case int8:
+		return int64(val), nil
+	case int16:
+		return int64(val), nil
+	case int32:
+		return int64(val), nil
+	case int64:
+		return val, nil
+	case uint8:
+		return int64(val), nil
+	case uint16:
+		return int64(val), nil
+	case uint32:
+		return int64(val), nil
+	case uint:
+		if uint64(val) > math.MaxInt64 {
+			return 0, fmt.Errorf("%v is out of range", val)
+		}
+		return int64(val), nil
+	case uint64:
+		if val > math.MaxInt64 {
+			return 0, fmt.Errorf("%v is out of range", val)
+		}
+		return int64(val), nil
+	case float32:
+		return floatToInt64(float64(val))
+	case float64:
+		return floatToInt64(val)
+	case json.Number:
+		if result, err := strconv.ParseInt(val.String(), 10, 64); err == nil {
+			return result, nil
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid value '%s' for an integer", val)
+		}
+		return floatToInt64(f)
+	case string:
+		result, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value '%s' for an integer", val)
+		}
+		return result, nil
+	}
+	return 0, fmt.Errorf("invalid type %T for value %v", val, val)
+}
+
+// floatToInt64 converts float to int64 if it has no fractional part
+func floatToInt64(val float64) (int64, error) {
+	if val != math.Trunc(val) || val < math.MinInt64 || val >= math.MaxInt64 {
+		return 0, fmt.Errorf("%v is not an integer", val)
+	}
+	return int64(val), nil
+}
+
+// NewEnumIntMap returns a MapFunc that verifies that the argument is an
+// integer from the allowed set. The result is the string representation of
+// the integer, consistent with IntegerMap.
+func NewEnumIntMap(allowed ...int64) MapFunc {
+	set := make(map[int64]bool, len(allowed))
+	for _, v := range allowed {
+		set[v] = true
+	}
+	return func(src interface{}) (interface{}, error) {
+		val, err := toInt64(src)
+		if err != nil {
+			return "", err
+		}
+		if !set[val] {
+			return "", fmt.Errorf("%d is not one of %v", val, allowed)
+		}
+		return strconv.FormatInt(val, 10), nil
+	}
+}
+
+// NewScaleMap returns a MapFunc that multiplies a number by factor, e.g. to
+// convert kilobytes to bytes. The source can be a number or a numeric string.
+// The result is float64, so integers are exact up to 2^53.
+func NewScaleMap(factor float64) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		val, err := toFloat64(src)
+		if err != nil {
+			return nil, err
+		}
+		return val * factor, nil
+	}
+}
+
+// Multipliers for size suffixes, both SI (powers of 1000) and IEC (powers of
+// 1024)
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1000,
+	"KB":  1000,
+	"M":   1000 * 1000,
+	"MB":  1000 * 1000,
+	"G":   1000 * 1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"T":   1000 * 1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"P":   1000 * 1000 * 1000 * 1000 * 1000,
+	"PB":  1000 * 1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+	"PIB": 1 << 50,
+}
+
+// Human-readable size, e.g. "512MB" or "1.5 GiB"
+var validSize = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-zA-Z]*)$`)
+
+// FileSizeMap converts a human-readable size such as "512MB" or "2GiB" to the
+// number of bytes as int64. SI suffixes (KB, MB, ...) are powers of 1000 and
+// IEC suffixes (KiB, MiB, ...) are powers of 1024. Suffixes are case
+// insensitive. Numbers without suffix are interpreted as bytes. Fractional
+// sizes are allowed as long as they amount to a whole number of bytes.
+func FileSizeMap(src interface{}) (interface{}, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		val, err := toInt64(src)
+		if err != nil {
+			return nil, err
+		}
+		if val < 0 {
+			return nil, fmt.Errorf("%v should be non-negative", val)
+		}
+		return val, nil
+	}
+	srcStr = strings.TrimSpace(srcStr)
+	m := validSize.FindStringSubmatch(srcStr)
+	if m == nil {
+		return nil, fmt.Errorf("%s is not a valid size", srcStr)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(m[2])]
+	if !ok {
+		return nil, fmt.Errorf("%s has unknown size unit %s", srcStr, m[2])
+	}
+	size, ok := new(big.Rat).SetString(m[1])
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid size", srcStr)
+	}
+	size.Mul(size, new(big.Rat).SetInt64(unit))
+	if !size.IsInt() {
+		return nil, fmt.Errorf("%s is not a whole number of bytes", srcStr)
+	}
+	if !size.Num().IsInt64() {
+		return nil, fmt.Errorf("%s is too large", srcStr)
+	}
+	return size.Num().Int64(), nil
+}
+
+// NewBitflagsMap returns a MapFunc that expands an integer bitmask into the
+// list of names of the set flags. The names map is keyed by flag values which
+// should be single bits, e.g. {1: "read", 2: "write", 4: "exec"}. Names are
+// listed in the order of increasing flag value. Set bits without a name cause
+// an error unless ignoreUnknown is true.
+func NewBitflagsMap(names map[int64]string, ignoreUnknown bool) MapFunc {
+	flags := make([]int64, 0, len(names))
+	var known int64
+	for flag := range names {
+		flags = append(flags, flag)
+		known |= flag
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i] < flags[j] })
+	return func(src interface{}) (interface{}, error) {
+		val, err := toInt64(src)
+		if err != nil {
+			return nil, err
+		}
+		if val < 0 {
+			return nil, fmt.Errorf("%d should be non-negative", val)
+		}
+		if unknown := val &^ known; unknown != 0 && !ignoreUnknown {
+			return nil, fmt.Errorf("%d has unknown flags %#x", val, unknown)
+		}
+		result := []string{}
+		for _, flag := range flags {
+			if val&flag != 0 {
+				result = append(result, names[flag])
+			}
+		}
+		return result, nil
+	}
+}
+
+// NormalizeNumberStringMap parses a numeric string and formats it in the
+// shortest form that represents the same number, e.g. "1.2000" becomes "1.2"
+// and "1e3" becomes "1000". Non-numeric input is rejected.
+func NormalizeNumberStringMap(src interface{}) (interface{}, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		return "", fmt.Errorf("%v is not a string", src)
+	}
+	val, err := strconv.ParseFloat(strings.TrimSpace(srcStr), 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		return "", fmt.Errorf("invalid value '%s' for a number", srcStr)
+	}
+	return strconv.FormatFloat(val, 'f', -1, 64), nil
+}
+
+// RoundMode defines how NewFloatToIntMap rounds fractional values
+type RoundMode int
+
+const (
+	// RoundTowardZero truncates the fractional part
+	RoundTowardZero RoundMode = iota
+	// RoundFloor rounds toward negative infinity
+	RoundFloor
+	// RoundCeil rounds toward positive infinity
+	RoundCeil
+	// RoundNearest rounds to the nearest integer, with halves rounded away
+	// from zero
+	RoundNearest
+)
+
+// NewFloatToIntMap returns a MapFunc that converts a number or a numeric
+// string to int64 using the given rounding mode. NaN, infinities and values
+// outside of the int64 range are rejected.
+func NewFloatToIntMap(mode RoundMode) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		val, err := toFloat64(src)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return nil, fmt.Errorf("%v is not a finite number", val)
+		}
+		switch mode {
+		case RoundTowardZero:
+			val = math.Trunc(val)
+		case RoundFloor:
+			val = math.Floor(val)
+		case RoundCeil:
+			val = math.Ceil(val)
+		case RoundNearest:
+			val = math.Round(val)
+		default:
+			return nil, NewInternalError(
+				fmt.Sprintf("unknown rounding mode %d", mode))
+		}
+		if val < math.MinInt64 || val >= math.MaxInt64 {
+			return nil, fmt.Errorf("%v is out of range", val)
+		}
+		return int64(val), nil
+	}
+}
+
+// NewIntToEnumMap returns a MapFunc that maps integer codes to string labels,
+// e.g. {1: "active", 2: "disabled"}. Codes without a label are rejected
+// unless passThrough is true, in which case the code is returned as a string,
+// consistent with IntegerMap.
+func NewIntToEnumMap(labels map[int64]string, passThrough bool) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		val, err := toInt64(src)
+		if err != nil {
+			return "", err
+		}
+		if label, ok := labels[val]; ok {
+			return label, nil
+		}
+		if !passThrough {
+			return "", fmt.Errorf("%d is not a known code", val)
+		}
+		return strconv.FormatInt(val, 10), nil
+	}
+}
+
+// NewLocalizedIntegerMap returns a MapFunc that parses integers formatted with
+// a grouping separator, e.g. "1,024,000" with sep ",". Groups must have three
+// digits except the first one, so "1,02" is rejected, but numbers without
+// separators are accepted as well. Numeric values are accepted as is. Like
+// IntegerMap, the result is the canonical string representation.
+func NewLocalizedIntegerMap(sep string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			val, err := toInt64(src)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatInt(val, 10), nil
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		digits := strings.TrimLeft(srcStr, "+-")
+		sign := srcStr[:len(srcStr)-len(digits)]
+		if len(sign) > 1 {
+			return "", fmt.Errorf("invalid value '%s' for an integer", srcStr)
+		}
+		if sep != "" && strings.Contains(digits, sep) {
+			groups := strings.Split(digits, sep)
+			for i, g := range groups {
+				if len(g) == 0 || len(g) > 3 || (i > 0 && len(g) != 3) {
+					return "", fmt.Errorf("invalid grouping in '%s'", srcStr)
+				}
+			}
+			digits = strings.Join(groups, "")
+		}
+		val, err := strconv.ParseInt(sign+digits, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid value '%s' for an integer", srcStr)
+		}
+		return strconv.FormatInt(val, 10), nil
+	}
+}
+
+// Decimal number with optional sign and fractional part
+var validDecimal = regexp.MustCompile(`^([+-]?)(\d+)(?:\.(\d*))?$`)
+
+// NewMoneyMap returns a MapFunc that converts an amount in major units, e.g.
+// dollars, to an int64 amount in minor units, e.g. cents, where decimals is
+// the number of minor unit digits. For example "12.34" with decimals 2 becomes
+// 1234. Strings are parsed directly without floating point rounding. Amounts
+// with more significant decimal places than decimals are rejected. A negative
+// decimals is reported as InternalError.
+func NewMoneyMap(decimals int) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		if decimals < 0 {
+			return nil, NewInternalError(
+				fmt.Sprintf("invalid number of decimal places %d", decimals))
+		}
+		var str string
+		switch src := src.(type) {
+		case string:
+			str = strings.TrimSpace(src)
+		case json.Number:
+			str = src.String()
+		case float32:
+			str = strconv.FormatFloat(float64(src), 'f', -1, 32)
+		case float64:
+			str = strconv.FormatFloat(src, 'f', -1, 64)
+		default:
+			val, err := toInt64(src)
+			if err != nil {
+				return nil, err
+			}
+			str = strconv.FormatInt(val, 10)
+		}
+		m := validDecimal.FindStringSubmatch(str)
+		if m == nil {
+			return nil, fmt.Errorf("invalid value '%s' for an amount", str)
+		}
+		frac := strings.TrimRight(m[3], "0")
+		if len(frac) > decimals {
+			return nil, fmt.Errorf("%s has more than %d decimal places",
+				str, decimals)
+		}
+		frac += strings.Repeat("0", decimals-len(frac))
+		result, err := strconv.ParseInt(m[1]+m[2]+frac, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is out of range", str)
+		}
+		return result, nil
+	}
+}
+
+// NewGreaterThanMap returns a MapFunc that verifies that a number or a
+// numeric string is greater than min, or equal to it if inclusive is true.
+// The source value is returned unchanged.
+func NewGreaterThanMap(min float64, inclusive bool) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		val, err := toFloat64(src)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(val) || val < min || (!inclusive && val == min) {
+			if inclusive {
+				return nil, fmt.Errorf("%v should be at least %v", val, min)
+			}
+			return nil, fmt.Errorf("%v should be greater than %v", val, min)
+		}
+		return src, nil
+	}
+}
+
+// NewLessThanMap returns a MapFunc that verifies that a number or a numeric
+// string is less than max, or equal to it if inclusive is true. The source
+// value is returned unchanged.
+func NewLessThanMap(max float64, inclusive bool) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		val, err := toFloat64(src)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(val) || val > max || (!inclusive && val == max) {
+			if inclusive {
+				return nil, fmt.Errorf("%v should be at most %v", val, max)
+			}
+			return nil, fmt.Errorf("%v should be less than %v", val, max)
+		}
+		return src, nil
+	}
+}
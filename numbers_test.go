@@ -0,0 +1,265 @@
+package maptrans
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnumIntMap(t *testing.T) {
+	t.Parallel()
+	enum := NewEnumIntMap(0, 1, 2)
+	for _, v := range []interface{}{1, "1", 1.0, int64(1), json.Number("1"),
+		json.Number("1.0"), int8(1), int16(1), uint8(1), uint16(1)} {
+		res, err := enum(v)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", res)
+	}
+	_, err := enum(3)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "[0 1 2]")
+	}
+	_, err = enum(1.5)
+	assert.Error(t, err, "Error expected")
+	_, err = enum(json.Number("1.5"))
+	assert.Error(t, err, "Error expected")
+	_, err = enum("x")
+	assert.Error(t, err, "Error expected")
+	_, err = enum(true)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestScaleMap(t *testing.T) {
+	t.Parallel()
+	kb := NewScaleMap(1024)
+	for _, v := range []interface{}{2, "2", 2.0, " 2 "} {
+		res, err := kb(v)
+		assert.NoError(t, err)
+		assert.Equal(t, 2048.0, res)
+	}
+	res, err := NewScaleMap(1000)("1.5")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500.0, res)
+	_, err = kb("x")
+	assert.Error(t, err, "Error expected")
+	_, err = kb(nil)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestFileSizeMap(t *testing.T) {
+	t.Parallel()
+	for src, expected := range map[string]int64{
+		"1KiB":    1024,
+		"1KB":     1000,
+		"512 MB":  512000000,
+		"2GiB":    2147483648,
+		"0.5 kib": 512,
+		"1.5GB":   1500000000,
+		"100":     100,
+		"7B":      7,
+	} {
+		res, err := FileSizeMap(src)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, res, src)
+	}
+	res, err := FileSizeMap(4096)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4096), res)
+
+	for _, bad := range []interface{}{"1.5B", "12XB", "MB", "-1KB",
+		"99999999PiB", -1, true} {
+		_, err = FileSizeMap(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestBitflagsMap(t *testing.T) {
+	t.Parallel()
+	names := map[int64]string{1: "read", 2: "write", 4: "exec"}
+	flags := NewBitflagsMap(names, false)
+	res, err := flags(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"read", "exec"}, res)
+	res, err = flags("0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, res)
+	_, err = flags(9)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "0x8")
+	}
+	_, err = flags(-1)
+	assert.Error(t, err, "Error expected")
+
+	res, err = NewBitflagsMap(names, true)(10.0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"write"}, res)
+}
+
+func TestNormalizeNumberStringMap(t *testing.T) {
+	t.Parallel()
+	for src, expected := range map[string]string{
+		"1.2000":  "1.2",
+		" 1.0 ":   "1",
+		"007":     "7",
+		"-0.50":   "-0.5",
+		"1e3":     "1000",
+		"0.00001": "0.00001",
+	} {
+		res, err := NormalizeNumberStringMap(src)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, res)
+	}
+	for _, bad := range []interface{}{"x", "", "NaN", "Inf", 1.5} {
+		_, err := NormalizeNumberStringMap(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestFloatToIntMap(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		mode     RoundMode
+		src      interface{}
+		expected int64
+	}{
+		{RoundTowardZero, 2.7, 2},
+		{RoundTowardZero, -2.7, -2},
+		{RoundFloor, -2.1, -3},
+		{RoundFloor, "2.9", 2},
+		{RoundCeil, 2.1, 3},
+		{RoundCeil, -2.9, -2},
+		{RoundNearest, 2.5, 3},
+		{RoundNearest, -2.5, -3},
+		{RoundNearest, 2.4, 2},
+		{RoundNearest, 7, 7},
+	} {
+		res, err := NewFloatToIntMap(tc.mode)(tc.src)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, res, "%v with mode %d", tc.src, tc.mode)
+	}
+	for _, bad := range []interface{}{math.NaN(), math.Inf(1), "NaN", 1e20,
+		"x", true} {
+		_, err := NewFloatToIntMap(RoundNearest)(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+	_, err := NewFloatToIntMap(RoundMode(42))(1.0)
+	_, ok := err.(*InternalError)
+	assert.True(t, ok)
+}
+
+func TestIntToEnumMap(t *testing.T) {
+	t.Parallel()
+	labels := map[int64]string{1: "active", 2: "disabled"}
+	res, err := NewIntToEnumMap(labels, false)(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "active", res)
+	res, err = NewIntToEnumMap(labels, false)(" 2")
+	assert.NoError(t, err)
+	assert.Equal(t, "disabled", res)
+	_, err = NewIntToEnumMap(labels, false)(3)
+	assert.Error(t, err, "Error expected")
+	res, err = NewIntToEnumMap(labels, true)(3.0)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", res)
+	_, err = NewIntToEnumMap(labels, true)("x")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestLocalizedIntegerMap(t *testing.T) {
+	t.Parallel()
+	for sep, cases := range map[string]map[interface{}]string{
+		",": {
+			"1,024,000": "1024000",
+			" -1,000 ":  "-1000",
+			"999":       "999",
+			"+12,345":   "12345",
+			42:          "42",
+		},
+		".": {"1.024.000": "1024000"},
+		" ": {"1 024": "1024"},
+	} {
+		for src, expected := range cases {
+			res, err := NewLocalizedIntegerMap(sep)(src)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, res, "%v with separator '%s'", src, sep)
+		}
+	}
+	for _, bad := range []interface{}{"1,02", "1,0000", ",100", "1,,000",
+		"1.5", "--1", "x", "", true} {
+		_, err := NewLocalizedIntegerMap(",")(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
+
+func TestMoneyMap(t *testing.T) {
+	t.Parallel()
+	for src, expected := range map[interface{}]int64{
+		"12.34":             1234,
+		" 12.3 ":            1230,
+		"12":                1200,
+		"12.":               1200,
+		"-0.01":             -1,
+		"12.340":            1234,
+		0.29:                29,
+		float32(0.1):        10,
+		7:                   700,
+		json.Number("1.05"): 105,
+	} {
+		res, err := NewMoneyMap(2)(src)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, res, "%v", src)
+	}
+	res, err := NewMoneyMap(0)("15")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), res)
+	for _, bad := range []interface{}{"12.345", "1e3", "abc", ".5", "",
+		"99999999999999999999", true, math.NaN()} {
+		_, err := NewMoneyMap(2)(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+	_, err = NewMoneyMap(-1)("15")
+	assert.IsType(t, &InternalError{}, err)
+}
+
+func TestGreaterLessThanMap(t *testing.T) {
+	t.Parallel()
+	positive := NewGreaterThanMap(0, false)
+	res, err := positive(" 1 ")
+	assert.NoError(t, err)
+	assert.Equal(t, " 1 ", res)
+	_, err = positive(0)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "greater than 0")
+	}
+	_, err = NewGreaterThanMap(0, true)(0)
+	assert.NoError(t, err)
+	_, err = NewGreaterThanMap(0, true)(-0.5)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "at least 0")
+	}
+
+	_, err = NewLessThanMap(100, false)(99.9)
+	assert.NoError(t, err)
+	_, err = NewLessThanMap(100, false)(100)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "less than 100")
+	}
+	_, err = NewLessThanMap(100, true)("100")
+	assert.NoError(t, err)
+	_, err = NewLessThanMap(100, true)(uint8(100))
+	assert.NoError(t, err)
+	_, err = NewLessThanMap(100, true)(int16(101))
+	assert.Error(t, err, "Error expected")
+	_, err = NewLessThanMap(100, true)(101)
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "at most 100")
+	}
+	for _, bad := range []interface{}{"x", "NaN", true} {
+		_, err = positive(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+		_, err = NewLessThanMap(1, true)(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+}
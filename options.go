@@ -0,0 +1,171 @@
+package maptrans
+
+import "context"
+
+// Option configures translation performed by TranslateWithOptions
+type Option func(*translator)
+
+// WithStrict rejects source fields that are not mentioned in the description
+// (see TranslateStrict).
+func WithStrict() Option {
+	return func(t *translator) {
+		t.strict = true
+	}
+}
+
+// WithMaxDepth limits the nesting depth of translated maps. The top-level map
+// has depth 1. Exceeding the limit is reported as InvalidPropertyError.
+// Zero means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(t *translator) {
+		t.maxDepth = depth
+	}
+}
+
+// WithContext passes ctx to CtxMapFunc functions (see TranslateContext).
+func WithContext(ctx context.Context) Option {
+	return func(t *translator) {
+		t.ctx = ctx
+	}
+}
+
+// WithCollectErrors collects all field errors instead of stopping at the
+// first one (see TranslateAll).
+func WithCollectErrors() Option {
+	return func(t *translator) {
+		t.collect = true
+	}
+}
+
+// WithKeyFunc applies keyFunc to source keys before matching them against the
+// description (see TranslateWithKeyFunc).
+func WithKeyFunc(keyFunc KeyFunc) Option {
+	return func(t *translator) {
+		t.keyFunc = keyFunc
+	}
+}
+
+// WithStats accumulates translation counters in stats (see
+// TranslateWithStats).
+func WithStats(stats *TranslateStats) Option {
+	return func(t *translator) {
+		t.stats = stats
+	}
+}
+
+// TranslateWithOptions translates src using description like Translate,
+// with the behavior adjusted by the given options. Translate is equivalent to
+// TranslateWithOptions without options.
+func TranslateWithOptions(src map[string]interface{},
+	description map[string]interface{},
+	opts ...Option) (map[string]interface{}, error) {
+	t := &translator{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t.run(src, description)
+}
+
+// WithGuardedModify prevents ModFunc functions from modifying or removing keys
+// that are already present in the destination map. A violation is reported as
+// InternalError since it indicates conflicting descriptions.
+func WithGuardedModify() Option {
+	return func(t *translator) {
+		t.guardModify = true
+	}
+}
+
+// WithConsumedKeys stores the sorted paths of source fields that matched a
+// description entry in keys (see TranslateWithKeys).
+func WithConsumedKeys(keys *[]string) Option {
+	return func(t *translator) {
+		t.consumed = keys
+	}
+}
+
+// WithMaxFields limits the number of keys in every translated source map,
+// including nested objects and array elements. Exceeding the limit is
+// reported as InvalidPropertyError. Zero means no limit.
+func WithMaxFields(n int) Option {
+	return func(t *translator) {
+		t.maxFields = n
+	}
+}
+
+// WithWarnings stores sorted warnings about deprecated source fields (see
+// Description.Deprecated) and about array elements dropped because of
+// SkipInvalid in warnings. Deprecated fields are still translated.
+func WithWarnings(warnings *[]string) Option {
+	return func(t *translator) {
+		t.warnings = warnings
+	}
+}
+
+// WithPreserveOriginals stores a shallow copy of the source map in the result
+// under key, which is useful for comparing values before and after a schema
+// migration. Only the top-level result gets the copy; nested source objects
+// are available within it unchanged. A result which already has key is
+// reported as InternalError.
+func WithPreserveOriginals(key string) Option {
+	return func(t *translator) {
+		t.originalKey = key
+	}
+}
+
+// WithResultValidator runs validator once on the top-level result after all
+// fields are translated successfully. A non-nil error fails the translation
+// and is returned as is.
+func WithResultValidator(validator ResultValidator) Option {
+	return func(t *translator) {
+		t.validator = validator
+	}
+}
+
+// WithFieldTransform applies MapFuncs to fields of the top-level result after
+// translation, keyed by target name, regardless of the source field which
+// produced the value. Fields missing from the result are left alone. A field
+// for which the MapFunc returns ErrOmit is removed from the result. Other
+// failures are reported as InvalidPropertyError for the target name.
+func WithFieldTransform(transforms map[string]MapFunc) Option {
+	return func(t *translator) {
+		t.transforms = transforms
+	}
+}
+
+// TypeMismatchMode defines how source values with a wrong type for
+// MapTranslation, MapArrayTranslation or MapValueScalarTranslation are
+// handled, e.g. a string where an object is expected.
+type TypeMismatchMode int
+
+const (
+	// TypeMismatchInternal reports the mismatch as InternalError (default)
+	TypeMismatchInternal TypeMismatchMode = iota
+	// TypeMismatchInvalid reports the mismatch as InvalidPropertyError
+	TypeMismatchInvalid
+	// TypeMismatchSkip drops the field from the result
+	TypeMismatchSkip
+)
+
+// WithTypeMismatch sets the handling of wrong-typed values of nested
+// translations. Since such values usually come from bad input, reporting them
+// as InvalidPropertyError or skipping them avoids treating bad input as an
+// internal error.
+func WithTypeMismatch(mode TypeMismatchMode) Option {
+	return func(t *translator) {
+		t.typeMode = mode
+	}
+}
+
+// WithOutputFields keeps only the listed target names in the top-level
+// result, so one description can produce different views. The projection is
+// applied after WithFieldTransform and WithResultValidator, which see the
+// complete result. It also applies to the WithPreserveOriginals key, which must
+// be listed to keep the original values.
+func WithOutputFields(names ...string) Option {
+	return func(t *translator) {
+		t.output = make(map[string]bool, len(names))
+		for _, name := range names {
+			t.output[name] = true
+		}
+	}
+}
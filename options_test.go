@@ -0,0 +1,408 @@
+package maptrans
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateWithOptions(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"id":   Description{MapFunc: IntegerMap},
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"inner": Description{
+					Type:           MapTranslation,
+					SubTranslation: map[string]interface{}{"a": "A"},
+				},
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name": "foo",
+		"id":   "x",
+		"info": map[string]interface{}{
+			"inner": map[string]interface{}{"a": "b"},
+		},
+		"extra": true,
+	}
+	stats := TranslateStats{}
+	_, err := TranslateWithOptions(src, descr, WithStrict(),
+		WithCollectErrors(), WithStats(&stats))
+	assert.Equal(t, map[string]string{
+		"extra": "unknown attribute 'extra'",
+		"id":    "invalid value 'x' for an integer",
+	}, TranslateErrorsToMap(err))
+	assert.Equal(t, 2, stats.Errors)
+
+	src["id"] = 1
+	delete(src, "extra")
+	_, err = TranslateWithOptions(src, descr, WithMaxDepth(2))
+	assert.Error(t, err, "Error expected")
+	stats = TranslateStats{}
+	_, err = TranslateWithOptions(src, descr, WithMaxDepth(2),
+		WithCollectErrors(), WithStats(&stats))
+	assert.Equal(t, map[string]string{
+		"info.inner": "maximum nesting depth 2 exceeded",
+	}, TranslateErrorsToMap(err))
+	assert.Equal(t, 1, stats.Errors)
+	dst, err := TranslateWithOptions(src, descr, WithMaxDepth(3))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name": "foo",
+		"id":   "1",
+		"info": map[string]interface{}{
+			"inner": map[string]interface{}{"A": "b"},
+		},
+	}, dst)
+}
+
+func TestWithGuardedModify(t *testing.T) {
+	t.Parallel()
+	setKind := func(kind string) ModFunc {
+		return func(src, dst map[string]interface{}, v interface{}) error {
+			dst["kind"] = kind
+			return nil
+		}
+	}
+	descr := map[string]interface{}{
+		"a": Description{Type: ModifyTranslation, ModFunc: setKind("a")},
+		"b": Description{Type: ModifyTranslation, ModFunc: setKind("b")},
+		"c": Description{Type: ModifyTranslation, ModFunc: setKind("a")},
+	}
+	dst, err := TranslateWithOptions(map[string]interface{}{"a": 1},
+		descr, WithGuardedModify())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"kind": "a"}, dst)
+
+	// Setting the same value is not a conflict
+	_, err = TranslateWithOptions(map[string]interface{}{"a": 1, "c": 1},
+		descr, WithGuardedModify())
+	assert.NoError(t, err)
+
+	src := map[string]interface{}{"a": 1, "b": 1}
+	_, err = Translate(src, descr)
+	assert.NoError(t, err)
+	_, err = TranslateWithOptions(src, descr, WithGuardedModify())
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &InternalError{}, err)
+	}
+}
+
+func TestWithMaxFields(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"info": Description{
+			Type:           MapTranslation,
+			SubTranslation: map[string]interface{}{"a": "A"},
+		},
+	}
+	src := map[string]interface{}{
+		"name": "foo",
+		"info": map[string]interface{}{"a": "b", "c": "d", "e": "f"},
+	}
+	_, err := TranslateWithOptions(src, descr, WithMaxFields(2))
+	if assert.Error(t, err, "Error expected") {
+		propErr, ok := err.(*InvalidPropertyError)
+		if assert.True(t, ok) {
+			assert.Equal(t, "info", propErr.Name)
+		}
+	}
+	_, err = TranslateWithOptions(src, descr, WithMaxFields(1))
+	assert.Error(t, err, "Error expected")
+	stats := TranslateStats{}
+	_, err = TranslateWithOptions(src, descr, WithMaxFields(2),
+		WithCollectErrors(), WithStats(&stats))
+	assert.Equal(t, map[string]string{
+		"info": "3 fields exceed maximum of 2",
+	}, TranslateErrorsToMap(err))
+	assert.Equal(t, map[FieldFailure]int{{Path: "info", Category: "invalid"}: 1},
+		stats.Failures)
+	dst, err := TranslateWithOptions(src, descr, WithMaxFields(3))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name": "foo",
+		"info": map[string]interface{}{"A": "b"},
+	}, dst)
+}
+
+func TestLimitsNilDescription(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"info": Description{Type: MapTranslation},
+	}
+	src := map[string]interface{}{
+		"info": map[string]interface{}{"a": "b", "c": "d", "e": "f"},
+	}
+	_, err := TranslateWithOptions(src, descr, WithMaxFields(2))
+	assert.Error(t, err, "Error expected")
+	_, err = TranslateWithOptions(src, descr, WithMaxDepth(1))
+	assert.Error(t, err, "Error expected")
+	dst, err := TranslateWithOptions(src, descr, WithMaxFields(3),
+		WithMaxDepth(2))
+	assert.NoError(t, err)
+	assert.Equal(t, src, dst)
+
+	_, err = TranslateWithOptions(src["info"].(map[string]interface{}), nil,
+		WithMaxFields(2))
+	assert.Error(t, err, "Error expected")
+}
+
+func TestWithWarnings(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": Description{
+			TargetName:     "Name",
+			MapFunc:        StringMap,
+			Deprecated:     true,
+			DeprecationMsg: "use full_name",
+		},
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": Description{MapFunc: IPAddrMap, Deprecated: true},
+			},
+		},
+		"full_name": "FullName",
+	}
+	src := map[string]interface{}{
+		"name": "foo",
+		"info": map[string]interface{}{"ip": "1.2.3.4"},
+	}
+	var warnings []string
+	dst, err := TranslateWithOptions(src, descr, WithWarnings(&warnings))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name": "foo",
+		"info": map[string]interface{}{"ip": "1.2.3.4"},
+	}, dst)
+	assert.Equal(t, []string{
+		"field 'info.ip' is deprecated",
+		"field 'name' is deprecated: use full_name",
+	}, warnings)
+
+	warnings = nil
+	_, err = TranslateWithOptions(map[string]interface{}{"full_name": "foo"},
+		descr, WithWarnings(&warnings))
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestWithPreserveOriginals(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"info": Description{
+			Type:           MapTranslation,
+			SubTranslation: map[string]interface{}{"ip": "IP"},
+		},
+	}
+	src := map[string]interface{}{
+		"name":  " foo ",
+		"info":  map[string]interface{}{"ip": "1.2.3.4"},
+		"extra": 1,
+	}
+	dst, err := TranslateWithOptions(src, descr,
+		WithPreserveOriginals("_original"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Name":      "foo",
+		"info":      map[string]interface{}{"IP": "1.2.3.4"},
+		"_original": src,
+	}, dst)
+
+	_, err = TranslateWithOptions(src, descr, WithPreserveOriginals("Name"))
+	_, ok := err.(*InternalError)
+	assert.True(t, ok)
+
+	// Source is never modified, even without a description
+	src = map[string]interface{}{"a": 1}
+	dst, err = TranslateWithOptions(src, nil, WithPreserveOriginals("_orig"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1,
+		"_orig": map[string]interface{}{"a": 1}}, dst)
+	assert.Equal(t, map[string]interface{}{"a": 1}, src)
+}
+
+func TestWithResultValidator(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"start": Description{TargetName: "Start", MapFunc: NewScaleMap(1)},
+		"end":   Description{TargetName: "End", MapFunc: NewScaleMap(1)},
+	}
+	calls := 0
+	validator := func(result map[string]interface{}) error {
+		calls++
+		start, _ := result["Start"].(float64)
+		end, _ := result["End"].(float64)
+		if end < start {
+			return fmt.Errorf("end %v is before start %v", end, start)
+		}
+		return nil
+	}
+	dst, err := TranslateWithOptions(map[string]interface{}{
+		"start": 1, "end": 2}, descr, WithResultValidator(validator))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Start": 1.0, "End": 2.0}, dst)
+	_, err = TranslateWithOptions(map[string]interface{}{
+		"start": 2, "end": 1}, descr, WithResultValidator(validator))
+	assert.Error(t, err, "Error expected")
+	assert.Equal(t, 2, calls)
+
+	// The validator isn't called when translation fails
+	_, err = TranslateWithOptions(map[string]interface{}{"start": "x"},
+		descr, WithResultValidator(validator), WithCollectErrors())
+	assert.Error(t, err, "Error expected")
+	assert.Equal(t, 2, calls)
+}
+
+func TestWithFieldTransform(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":     "Name",
+		"fullname": "Name",
+		"id":       Description{TargetName: "ID", MapFunc: IDMap},
+	}
+	transforms := map[string]MapFunc{
+		"Name":    StringToUpperMap,
+		"ID":      IntegerMap,
+		"Missing": IntegerMap,
+	}
+	dst, err := TranslateWithOptions(map[string]interface{}{"fullname": "foo",
+		"id": 1}, descr, WithFieldTransform(transforms))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "FOO", "ID": "1"}, dst)
+
+	_, err = TranslateWithOptions(map[string]interface{}{"id": "x"}, descr,
+		WithFieldTransform(transforms))
+	assert.IsType(t, &InvalidPropertyError{}, err)
+	_, err = TranslateWithOptions(map[string]interface{}{"id": "x"}, descr,
+		WithFieldTransform(transforms), WithCollectErrors())
+	assert.Equal(t, map[string]string{
+		"ID": "invalid value 'x' for an integer",
+	}, TranslateErrorsToMap(err))
+
+	// Source is never modified, even without a description
+	src := map[string]interface{}{"Name": "foo", "ID": "x"}
+	_, err = TranslateWithOptions(src, nil, WithFieldTransform(transforms),
+		WithCollectErrors())
+	assert.Error(t, err, "Error expected")
+	dst, err = TranslateWithOptions(map[string]interface{}{"Name": "foo"}, nil,
+		WithFieldTransform(transforms))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "FOO"}, dst)
+	assert.Equal(t, map[string]interface{}{"Name": "foo", "ID": "x"}, src)
+
+	// ErrOmit drops the field
+	dst, err = TranslateWithOptions(map[string]interface{}{"name": "", "id": 1},
+		descr, WithFieldTransform(map[string]MapFunc{
+			"Name": NewConditionalDropMap(func(v interface{}) bool {
+				return v == ""
+			}, IDMap),
+		}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ID": 1}, dst)
+}
+
+func TestWithTypeMismatch(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"info": Description{
+			Type:           MapTranslation,
+			SubTranslation: map[string]interface{}{"ip": "IP"},
+		},
+		"routes": Description{
+			Type:           MapArrayTranslation,
+			SubTranslation: map[string]interface{}{"gw": "GW"},
+		},
+		"labels": Description{
+			Type:    MapValueScalarTranslation,
+			MapFunc: StringMap,
+		},
+	}
+	for _, field := range []string{"info", "routes", "labels"} {
+		src := map[string]interface{}{"name": "foo", field: "bad"}
+		_, err := Translate(src, descr)
+		assert.IsType(t, &InternalError{}, err, field)
+		_, err = TranslateWithOptions(src, descr,
+			WithTypeMismatch(TypeMismatchInvalid))
+		if assert.IsType(t, &InvalidPropertyError{}, err, field) {
+			assert.Equal(t, field, err.(*InvalidPropertyError).Name)
+		}
+		dst, err := TranslateWithOptions(src, descr,
+			WithTypeMismatch(TypeMismatchSkip))
+		assert.NoError(t, err, field)
+		assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst, field)
+	}
+
+	_, err := TranslateWithOptions(map[string]interface{}{"info": 1,
+		"routes": 2}, descr, WithTypeMismatch(TypeMismatchInvalid),
+		WithCollectErrors())
+	assert.Equal(t, map[string]string{
+		"info":   "invalid type int",
+		"routes": "invalid type int",
+	}, TranslateErrorsToMap(err))
+}
+
+func TestWithOutputFields(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"id":   Description{TargetName: "ID", MapFunc: IntegerMap},
+		"info": Description{
+			Type:           MapTranslation,
+			SubTranslation: map[string]interface{}{"ip": "IP"},
+		},
+	}
+	src := map[string]interface{}{
+		"name": "foo",
+		"id":   1,
+		"info": map[string]interface{}{"ip": "1.2.3.4"},
+	}
+	validated := false
+	dst, err := TranslateWithOptions(src, descr,
+		WithOutputFields("Name", "info", "Missing"),
+		WithResultValidator(func(result map[string]interface{}) error {
+			_, validated = result["ID"]
+			return nil
+		}))
+	assert.NoError(t, err)
+	assert.True(t, validated)
+	assert.Equal(t, map[string]interface{}{
+		"Name": "foo",
+		"info": map[string]interface{}{"IP": "1.2.3.4"},
+	}, dst)
+
+	dst, err = TranslateWithOptions(src, descr, WithOutputFields())
+	assert.NoError(t, err)
+	assert.Empty(t, dst)
+	_, err = TranslateWithOptions(map[string]interface{}{"id": "x"}, descr,
+		WithOutputFields("Name"))
+	assert.Error(t, err, "Error expected")
+
+	// Source is never modified, even without a description
+	src = map[string]interface{}{"a": 1, "b": 2}
+	dst, err = TranslateWithOptions(src, nil, WithOutputFields("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, dst)
+
+	// The key for original values is projected as well
+	dst, err = TranslateWithOptions(src, nil, WithOutputFields("a"),
+		WithPreserveOriginals("_original"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, dst)
+	dst, err = TranslateWithOptions(src, nil, WithOutputFields("a", "_original"),
+		WithPreserveOriginals("_original"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a":         1,
+		"_original": map[string]interface{}{"a": 1, "b": 2},
+	}, dst)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, src)
+}
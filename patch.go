@@ -0,0 +1,96 @@
+package maptrans
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PatchOperation is a single JSON Patch (RFC 6902) operation. Only "add",
+// "remove" and "replace" operations are produced.
+type PatchOperation struct {
+	Op    string      // Operation name
+	Path  string      // JSON Pointer (RFC 6901) to the target location
+	Value interface{} // New value, not used by "remove"
+}
+
+// MarshalJSON encodes the operation in the RFC 6902 format
+func (op PatchOperation) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// TranslateWithPatch translates src like Translate and also returns a JSON
+// Patch which transforms src into the result. This provides a replayable
+// record of the translation, e.g. for audit logs.
+//
+// Objects present in both src and the result are compared recursively, so
+// the patch only touches fields that actually changed. Other values,
+// including arrays, are replaced as a whole. Operations are ordered by field
+// name at each level.
+func TranslateWithPatch(src map[string]interface{},
+	description map[string]interface{}) (map[string]interface{},
+	[]PatchOperation, error) {
+	dst, err := Translate(src, description)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dst, diffPatch(src, dst, "", []PatchOperation{}), nil
+}
+
+// diffPatch appends to ops the operations transforming src into dst. The path
+// is the JSON Pointer of src.
+func diffPatch(src, dst map[string]interface{}, path string,
+	ops []PatchOperation) []PatchOperation {
+	keys := make([]string, 0, len(src)+len(dst))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	for k := range dst {
+		if _, isPresent := src[k]; !isPresent {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		keyPath := path + "/" + escapePointer(k)
+		srcVal, inSrc := src[k]
+		dstVal, inDst := dst[k]
+		switch {
+		case !inDst:
+			ops = append(ops, PatchOperation{Op: "remove", Path: keyPath})
+		case !inSrc:
+			ops = append(ops,
+				PatchOperation{Op: "add", Path: keyPath, Value: dstVal})
+		case reflect.DeepEqual(srcVal, dstVal):
+		default:
+			srcMap, srcIsMap := srcVal.(map[string]interface{})
+			dstMap, dstIsMap := dstVal.(map[string]interface{})
+			if srcIsMap && dstIsMap {
+				ops = diffPatch(srcMap, dstMap, keyPath, ops)
+				continue
+			}
+			ops = append(ops,
+				PatchOperation{Op: "replace", Path: keyPath, Value: dstVal})
+		}
+	}
+	return ops
+}
+
+// JSON Pointer escaping of '~' and '/'
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointer escapes a key for use in a JSON Pointer
+func escapePointer(key string) string {
+	return pointerEscaper.Replace(key)
+}
@@ -0,0 +1,62 @@
+package maptrans
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateWithPatch(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":  "name",
+		"id":    Description{TargetName: "ID", MapFunc: IntegerMap},
+		"force": Description{MapFunc: BoolMap},
+		"info": Description{
+			Type: MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip":  "ip",
+				"a/b": "a~b",
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name":   " foo",
+		"id":     1,
+		"force":  "false",
+		"info":   map[string]interface{}{"ip": "1.2.3.4", "a/b": "x"},
+		"extra":  true,
+		"ignore": nil,
+	}
+	dst, patch, err := TranslateWithPatch(src, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, map[string]interface{}{
+		"name":  "foo",
+		"ID":    "1",
+		"force": false,
+		"info":  map[string]interface{}{"ip": "1.2.3.4", "a~b": "x"},
+	}, dst)
+	assert.Equal(t, []PatchOperation{
+		{Op: "add", Path: "/ID", Value: "1"},
+		{Op: "remove", Path: "/extra"},
+		{Op: "replace", Path: "/force", Value: false},
+		{Op: "remove", Path: "/id"},
+		{Op: "remove", Path: "/ignore"},
+		{Op: "remove", Path: "/info/a~1b"},
+		{Op: "add", Path: "/info/a~0b", Value: "x"},
+		{Op: "replace", Path: "/name", Value: "foo"},
+	}, patch)
+
+	data, err := json.Marshal(patch[1:3])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "remove", "path": "/extra"},
+		{"op": "replace", "path": "/force", "value": false}
+	]`, string(data))
+
+	_, _, err = TranslateWithPatch(map[string]interface{}{"id": "x"}, descr)
+	assert.Error(t, err, "Error expected")
+}
@@ -0,0 +1,70 @@
+package maptrans
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry of named MapFuncs which may be referenced by MapFuncName. Built-in
+// MapFuncs are registered under their Go names.
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]MapFunc{
+		"BoolMap":                  BoolMap,
+		"BoolToStrMap":             BoolToStrMap,
+		"CIDRMap":                  CIDRMap,
+		"EAN13Map":                 EAN13Map,
+		"FileSizeMap":              FileSizeMap,
+		"HostnameMap":              HostnameMap,
+		"IDMap":                    IDMap,
+		"IPAddrMap":                IPAddrMap,
+		"ISBN13Map":                ISBN13Map,
+		"IdentifierMap":            IdentifierMap,
+		"IntegerMap":               IntegerMap,
+		"JSONBoolMap":              JSONBoolMap,
+		"NonEmptyStringMap":        NonEmptyStringMap,
+		"NormalizeNumberStringMap": NormalizeNumberStringMap,
+		"NumberMap":                NumberMap,
+		"RawJSONMap":               RawJSONMap,
+		"StrictIntegerMap":         StrictIntegerMap,
+		"StringArrayMap":           StringArrayMap,
+		"StringMap":                StringMap,
+		"StringToLowerMap":         StringToLowerMap,
+		"StringToUpperMap":         StringToUpperMap,
+		"URLQueryEscapeMap":        URLQueryEscapeMap,
+		"URLQueryUnescapeMap":      URLQueryUnescapeMap,
+		"UTF8Map":                  UTF8Map,
+		"UUIDMap":                  UUIDMap,
+	}
+)
+
+// RegisterMapFunc registers fn under the given name so descriptions can refer
+// to it using MapFuncName. Registering an existing name replaces the previous
+// function.
+func RegisterMapFunc(name string, fn MapFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = fn
+}
+
+// LookupMapFunc returns the MapFunc registered under the given name
+func LookupMapFunc(name string) (MapFunc, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	fn, ok := registry[name]
+	return fn, ok
+}
+
+// resolveMapFunc returns the MapFunc of the description, looking it up by
+// name if needed. It returns nil if neither MapFunc nor MapFuncName is set.
+func resolveMapFunc(md Description) (MapFunc, error) {
+	if md.MapFunc != nil || md.MapFuncName == "" {
+		return md.MapFunc, nil
+	}
+	fn, ok := LookupMapFunc(md.MapFuncName)
+	if !ok {
+		return nil, NewInternalError(
+			fmt.Sprintf("unknown translation func '%s'", md.MapFuncName))
+	}
+	return fn, nil
+}
@@ -0,0 +1,43 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapFuncRegistry(t *testing.T) {
+	t.Parallel()
+	RegisterMapFunc("test.double", func(v interface{}) (interface{}, error) {
+		s, _ := v.(string)
+		return s + s, nil
+	})
+	descr := map[string]interface{}{
+		"id":   Description{TargetName: "ID", MapFuncName: "UUIDMap"},
+		"name": Description{MapFuncName: "test.double"},
+		"bad":  Description{MapFuncName: "test.missing"},
+	}
+	src := map[string]interface{}{
+		"id":   "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"name": "ab",
+	}
+	dst, err := Translate(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ID":   "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"name": "abab",
+	}, dst)
+
+	src["id"] = "x"
+	_, err = Translate(src, descr)
+	assert.Error(t, err, "Error expected")
+
+	_, err = Translate(map[string]interface{}{"bad": 1}, descr)
+	if assert.Error(t, err, "Error expected") {
+		assert.IsType(t, &InternalError{}, err)
+	}
+	_, ok := LookupMapFunc("IntegerMap")
+	assert.True(t, ok)
+	_, ok = LookupMapFunc("NumberMap")
+	assert.True(t, ok)
+}
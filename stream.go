@@ -0,0 +1,157 @@
+package maptrans
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ElementError describes a failure to translate a single element of an array.
+type ElementError struct {
+	Index int   // Index of the element in the source array
+	Err   error // Cause of the failure
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("element %d: %s", e.Index, e.Err.Error())
+}
+
+// Unwrap returns the underlying error
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// NewElementError returns an instance of ElementError for the given index
+func NewElementError(index int, err error) *ElementError {
+	return &ElementError{Index: index, Err: err}
+}
+
+// ArrayTranslator translates elements of an array one at a time, so callers
+// can drive translation themselves, e.g. feeding elements from their own
+// decoder into their own pipeline. It keeps track of the element index which
+// is used to report failures as *ElementError. An ArrayTranslator should not
+// be used concurrently.
+type ArrayTranslator struct {
+	description map[string]interface{}
+	opts        []Option
+	index       int
+}
+
+// NewArrayTranslator returns an ArrayTranslator which translates each element
+// using description and the given options. Options holding results, such as
+// WithStats, accumulate them across elements.
+func NewArrayTranslator(description map[string]interface{},
+	opts ...Option) *ArrayTranslator {
+	return &ArrayTranslator{description: description, opts: opts}
+}
+
+// Index returns the index of the next element
+func (a *ArrayTranslator) Index() int {
+	return a.index
+}
+
+// Translate translates the next element of the array. A failure is returned
+// as *ElementError. The index advances regardless of the outcome.
+func (a *ArrayTranslator) Translate(
+	elem map[string]interface{}) (map[string]interface{}, error) {
+	i := a.index
+	a.index++
+	result, err := TranslateWithOptions(elem, a.description, a.opts...)
+	if err != nil {
+		return nil, NewElementError(i, err)
+	}
+	return result, nil
+}
+
+// TranslateStream is like the TranslateStream function but uses the
+// description and options of the ArrayTranslator. Element indices continue
+// from previously translated elements.
+func (a *ArrayTranslator) TranslateStream(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array, got %v", tok)
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for first := true; dec.More(); first = false {
+		var src map[string]interface{}
+		if err := dec.Decode(&src); err != nil {
+			return NewElementError(a.index, err)
+		}
+		if src == nil {
+			return NewElementError(a.index,
+				fmt.Errorf("null is not an object"))
+		}
+		i := a.index
+		dst, err := a.Translate(src)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(dst)
+		if err != nil {
+			return NewElementError(i, err)
+		}
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	// Consume the closing bracket
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// TranslateStream reads a JSON array of objects from r, translates each object
+// using description and writes the resulting JSON array to w.
+//
+// Elements are decoded, translated and written one at a time, so memory usage
+// is bounded by the size of a single element rather than the whole array.
+// There is no internal buffering between reading and writing: a slow writer
+// blocks further reads from r, which provides natural backpressure.
+//
+// Numbers are decoded as json.Number rather than float64, so they keep their
+// exact textual representation. IntegerMap, NumberMap and IDMap accept
+// json.Number, and json.Number values are written back as JSON numbers.
+//
+// Translation aborts on the first element that can't be decoded or translated,
+// including null elements, and the error is returned as *ElementError.
+// Elements written before the failure remain in w and the output array is left
+// unterminated, so the output should be discarded when an error is returned.
+func TranslateStream(r io.Reader, w io.Writer,
+	description map[string]interface{}) error {
+	return NewArrayTranslator(description).TranslateStream(r, w)
+}
+
+// TranslateJSON translates a JSON object using description and returns the
+// result as JSON. As with TranslateStream, numbers are decoded as json.Number,
+// so large integer IDs survive the round trip intact when they are mapped with
+// IDMap or NumberMap. Note that IntegerMap produces strings, so its results
+// are written as JSON strings.
+func TranslateJSON(data []byte,
+	description map[string]interface{}) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var src map[string]interface{}
+	if err := dec.Decode(&src); err != nil {
+		return nil, err
+	}
+	dst, err := Translate(src, description)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(dst)
+}
@@ -0,0 +1,126 @@
+package maptrans
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateStream(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name": "Name",
+		"id": Description{
+			TargetName: "ID",
+			MapFunc:    IntegerMap,
+		},
+	}
+	src := `[{"name": " foo ", "id": 1}, {"name": "bar", "id": "2"}]`
+	var out bytes.Buffer
+	err := TranslateStream(strings.NewReader(src), &out, descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	result := []map[string]interface{}{}
+	if !assert.NoError(t, json.Unmarshal(out.Bytes(), &result)) {
+		t.FailNow()
+	}
+	assert.Equal(t, []map[string]interface{}{
+		{"Name": "foo", "ID": "1"},
+		{"Name": "bar", "ID": "2"},
+	}, result)
+
+	out.Reset()
+	err = TranslateStream(strings.NewReader("[]"), &out, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", out.String())
+}
+
+func TestTranslateStreamBad(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"id": Description{
+			TargetName: "ID",
+			MapFunc:    IntegerMap,
+		},
+	}
+	var out bytes.Buffer
+	err := TranslateStream(strings.NewReader(`{"id": 1}`), &out, descr)
+	assert.Error(t, err)
+
+	src := `[{"id": 1}, {"id": "x"}]`
+	err = TranslateStream(strings.NewReader(src), &out, descr)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	elemErr, ok := err.(*ElementError)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, elemErr.Index)
+
+	err = TranslateStream(strings.NewReader(`[{"id": 1}, null]`), &out, descr)
+	if assert.IsType(t, &ElementError{}, err) {
+		assert.Equal(t, 1, err.(*ElementError).Index)
+	}
+}
+
+func TestTranslateJSONNumbers(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"id":    Description{TargetName: "ID", MapFunc: NumberMap},
+		"raw":   Description{MapFunc: IDMap},
+		"count": Description{MapFunc: IntegerMap},
+		"price": Description{MapFunc: NumberMap},
+	}
+	src := `{"id": 9007199254740993, "raw": 12345678901234567890,
+		"count": 3, "price": 1.50}`
+	data, err := TranslateJSON([]byte(src), descr)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.JSONEq(t, `{"ID": 9007199254740993, "raw": 12345678901234567890,
+		"count": "3", "price": 1.50}`, string(data))
+	assert.Contains(t, string(data), `"ID":9007199254740993`)
+
+	_, err = TranslateJSON([]byte(`{"count": -1}`), descr)
+	assert.Error(t, err, "Error expected")
+	_, err = TranslateJSON([]byte(`[1]`), descr)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestArrayTranslator(t *testing.T) {
+	t.Parallel()
+	var stats TranslateStats
+	at := NewArrayTranslator(map[string]interface{}{
+		"id": Description{TargetName: "ID", MapFunc: IntegerMap},
+	}, WithStats(&stats))
+	res, err := at.Translate(map[string]interface{}{"id": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ID": "1"}, res)
+	_, err = at.Translate(map[string]interface{}{"id": "x"})
+	if assert.Error(t, err, "Error expected") {
+		elemErr, ok := err.(*ElementError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 1, elemErr.Index)
+		}
+	}
+	assert.Equal(t, 2, at.Index())
+	assert.Equal(t, 1, stats.Translated)
+	assert.Equal(t, 1, stats.Errors)
+
+	// Streamed elements continue the numbering
+	var out bytes.Buffer
+	err = at.TranslateStream(strings.NewReader(`[{"id": 2}, {"id": "y"}]`),
+		&out)
+	if assert.Error(t, err, "Error expected") {
+		elemErr, ok := err.(*ElementError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 3, elemErr.Index)
+		}
+	}
+	assert.Equal(t, `[{"ID":"2"}`, out.String())
+}
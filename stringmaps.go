@@ -0,0 +1,315 @@
+package maptrans
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NewSplitMap returns a MapFunc that splits a string into an array using sep
+// as a separator. Each piece is trimmed and empty pieces are dropped when
+// dropEmpty is true. The result is []string unless elem is not nil, in which
+// case elem is applied to every piece and the result is []interface{} with
+// the mapped values.
+func NewSplitMap(sep string, dropEmpty bool, elem MapFunc) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", src)
+		}
+		pieces := []string{}
+		for _, piece := range strings.Split(srcStr, sep) {
+			piece = strings.TrimSpace(piece)
+			if dropEmpty && piece == "" {
+				continue
+			}
+			pieces = append(pieces, piece)
+		}
+		if elem == nil {
+			return pieces, nil
+		}
+		result := make([]interface{}, len(pieces))
+		for i, piece := range pieces {
+			val, err := elem(piece)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			result[i] = val
+		}
+		return result, nil
+	}
+}
+
+// NewJoinMap returns a MapFunc that joins an array of strings into a single
+// string using sep as a separator. The source can be either []string or
+// []interface{} in which every element is a string.
+func NewJoinMap(sep string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		switch src := src.(type) {
+		case []string:
+			return strings.Join(src, sep), nil
+		case []interface{}:
+			pieces := make([]string, len(src))
+			for i, v := range src {
+				str, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("element %d: %v is not a string",
+						i, v)
+				}
+				pieces[i] = str
+			}
+			return strings.Join(pieces, sep), nil
+		}
+		return nil, fmt.Errorf("invalid type %T for %v", src, src)
+	}
+}
+
+// NewHashMap returns a MapFunc that replaces a string with the hex digest of
+// its hash. The string is trimmed before hashing. Supported algorithms are
+// "sha256", "sha1" and "md5".
+func NewHashMap(algo string) (MapFunc, error) {
+	var newHash func() hash.Hash
+	switch algo {
+	case "sha256":
+		newHash = sha256.New
+	case "sha1":
+		newHash = sha1.New
+	case "md5":
+		newHash = md5.New
+	default:
+		return nil, fmt.Errorf("unknown hash algorithm '%s'", algo)
+	}
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", src)
+		}
+		h := newHash()
+		h.Write([]byte(strings.TrimSpace(srcStr)))
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}, nil
+}
+
+// URLQueryEscapeMap percent-encodes a string so it can be safely placed in a
+// URL query
+func URLQueryEscapeMap(src interface{}) (interface{}, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		return "", fmt.Errorf("%v is not a string", src)
+	}
+	return url.QueryEscape(srcStr), nil
+}
+
+// URLQueryUnescapeMap decodes a percent-encoded URL query string. Malformed
+// percent sequences are rejected.
+func URLQueryUnescapeMap(src interface{}) (interface{}, error) {
+	srcStr, ok := src.(string)
+	if !ok {
+		return "", fmt.Errorf("%v is not a string", src)
+	}
+	result, err := url.QueryUnescape(srcStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid encoding of '%s': %v", srcStr, err)
+	}
+	return result, nil
+}
+
+// NewTrimAnyPrefixMap returns a MapFunc that removes one of the given
+// prefixes from a trimmed string. If several prefixes match, the longest one
+// is removed. If no prefix matches, the string is returned unchanged when
+// passThrough is true, otherwise an error is returned.
+func NewTrimAnyPrefixMap(passThrough bool, prefixes ...string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		match := -1
+		for i, prefix := range prefixes {
+			if strings.HasPrefix(srcStr, prefix) &&
+				(match < 0 || len(prefix) > len(prefixes[match])) {
+				match = i
+			}
+		}
+		if match < 0 {
+			if passThrough {
+				return srcStr, nil
+			}
+			return "", fmt.Errorf("%s doesn't start with any of %v",
+				srcStr, prefixes)
+		}
+		return strings.TrimPrefix(srcStr, prefixes[match]), nil
+	}
+}
+
+// NewTruncateMap returns a MapFunc that limits a trimmed string to maxRunes
+// runes. Longer strings are truncated and suffix (e.g. "...") is appended so
+// that the result including the suffix has exactly maxRunes runes. If suffix
+// itself is longer than maxRunes, the string is truncated without it. A
+// negative maxRunes is reported as InternalError.
+func NewTruncateMap(maxRunes int, suffix string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		if maxRunes < 0 {
+			return "", NewInternalError(
+				fmt.Sprintf("invalid maximum length %d", maxRunes))
+		}
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		if utf8.RuneCountInString(srcStr) <= maxRunes {
+			return srcStr, nil
+		}
+		runes := []rune(srcStr)
+		keep := maxRunes - utf8.RuneCountInString(suffix)
+		if keep < 0 {
+			return string(runes[:maxRunes]), nil
+		}
+		return string(runes[:keep]) + suffix, nil
+	}
+}
+
+// NewEnumNormalizeMap returns a MapFunc that matches a trimmed string against
+// the canonical values ignoring case and returns the canonical spelling, e.g.
+// "ACTIVE" becomes "active". Strings that don't match any value are rejected.
+func NewEnumNormalizeMap(canonical ...string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		for _, v := range canonical {
+			if strings.EqualFold(srcStr, v) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("%s is not one of %v", srcStr, canonical)
+	}
+}
+
+// NewStripControlCharsMap returns a MapFunc that removes Unicode control
+// characters, including null bytes, from a string and trims the result. If
+// keepWhitespace is true, tabs, newlines and carriage returns are preserved
+// inside the string.
+func NewStripControlCharsMap(keepWhitespace bool) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		stripped := strings.Map(func(r rune) rune {
+			if keepWhitespace && (r == '\t' || r == '\n' || r == '\r') {
+				return r
+			}
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, srcStr)
+		return strings.TrimSpace(stripped), nil
+	}
+}
+
+// NewPathMap returns a MapFunc that normalizes a filesystem path using
+// filepath.Clean, e.g. "/a/b/../c/" becomes "/a/c". If requireAbsolute is
+// true, relative paths are rejected. If rejectTraversal is true, paths which
+// still refer to a parent directory ("..") after cleaning are rejected.
+func NewPathMap(requireAbsolute bool, rejectTraversal bool) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		if srcStr == "" {
+			return "", fmt.Errorf("path is empty")
+		}
+		cleaned := filepath.Clean(srcStr)
+		if requireAbsolute && !filepath.IsAbs(cleaned) {
+			return "", fmt.Errorf("%s is not an absolute path", srcStr)
+		}
+		if rejectTraversal {
+			for _, elem := range strings.Split(filepath.ToSlash(cleaned), "/") {
+				if elem == ".." {
+					return "", fmt.Errorf("%s refers to a parent directory",
+						srcStr)
+				}
+			}
+		}
+		return cleaned, nil
+	}
+}
+
+// NewRedactMap returns a MapFunc that verifies that the value is a non-empty
+// string and replaces it with mask, e.g. "****". It is useful for producing
+// copies of objects which are safe to log.
+func NewRedactMap(mask string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%T is not a string", src)
+		}
+		if strings.TrimSpace(srcStr) == "" {
+			return "", fmt.Errorf("value is empty")
+		}
+		return mask, nil
+	}
+}
+
+// NewEnumMap returns a MapFunc that remaps enum values using table, e.g.
+// {"A": "active", "D": "disabled"}. The source string is trimmed before the
+// lookup. Values missing from the table are mapped to fallback, e.g. "other",
+// so new upstream values don't break translation. If fallback is empty,
+// values missing from the table are rejected.
+func NewEnumMap(table map[string]string, fallback string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		if val, ok := table[srcStr]; ok {
+			return val, nil
+		}
+		if fallback == "" {
+			return "", fmt.Errorf("%s is not a known value", srcStr)
+		}
+		return fallback, nil
+	}
+}
+
+// UTF8Map translates a string like StringMap but rejects strings which are
+// not valid UTF-8.
+func UTF8Map(src interface{}) (interface{}, error) {
+	return NewUTF8Map(false)(src)
+}
+
+// NewUTF8Map returns a MapFunc that verifies that a string is valid UTF-8 and
+// trims it. If repair is true, invalid byte sequences are replaced with the
+// Unicode replacement character U+FFFD instead of being rejected.
+func NewUTF8Map(repair bool) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		if !utf8.ValidString(srcStr) {
+			if !repair {
+				return "", fmt.Errorf("%q is not valid UTF-8", srcStr)
+			}
+			srcStr = strings.ToValidUTF8(srcStr, string(utf8.RuneError))
+		}
+		return strings.TrimSpace(srcStr), nil
+	}
+}
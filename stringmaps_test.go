@@ -0,0 +1,244 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewSplitMap(",", true, nil)(" a, b,,c ")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, res)
+
+	res, err = NewSplitMap(",", false, nil)("a,,b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "", "b"}, res)
+
+	res, err = NewSplitMap(",", true, IntegerMap)("1, 2")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"1", "2"}, res)
+
+	_, err = NewSplitMap(",", true, IntegerMap)("1, x")
+	assert.Error(t, err, "Error expected")
+	_, err = NewSplitMap(",", true, nil)(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestJoinMap(t *testing.T) {
+	t.Parallel()
+	join := NewJoinMap(",")
+	res, err := join([]string{"a", "b", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b,c", res)
+
+	res, err = join([]interface{}{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b", res)
+
+	res, err = join([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", res)
+
+	_, err = join([]interface{}{"a", 1})
+	assert.Error(t, err, "Error expected")
+	_, err = join("a")
+	assert.Error(t, err, "Error expected")
+
+	split, err := NewSplitMap(",", true, nil)("a,b,c")
+	assert.NoError(t, err)
+	res, err = join(split)
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b,c", res)
+}
+
+func TestHashMap(t *testing.T) {
+	t.Parallel()
+	sha, err := NewHashMap("sha256")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	res, err := sha(" abc ")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		res)
+	_, err = sha(1)
+	assert.Error(t, err, "Error expected")
+
+	md, err := NewHashMap("md5")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	res, err = md("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "900150983cd24fb0d6963f7d28e17f72", res)
+
+	_, err = NewHashMap("sha3")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestURLQueryMaps(t *testing.T) {
+	t.Parallel()
+	res, err := URLQueryEscapeMap("a b&c=d")
+	assert.NoError(t, err)
+	assert.Equal(t, "a+b%26c%3Dd", res)
+	res, err = URLQueryUnescapeMap(res)
+	assert.NoError(t, err)
+	assert.Equal(t, "a b&c=d", res)
+	_, err = URLQueryUnescapeMap("100%zz")
+	assert.Error(t, err, "Error expected")
+	_, err = URLQueryEscapeMap(1)
+	assert.Error(t, err, "Error expected")
+	_, err = URLQueryUnescapeMap(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestTrimAnyPrefixMap(t *testing.T) {
+	t.Parallel()
+	trim := NewTrimAnyPrefixMap(false, "usr_", "org_", "org_team_")
+	res, err := trim(" usr_123")
+	assert.NoError(t, err)
+	assert.Equal(t, "123", res)
+	res, err = trim("org_team_7")
+	assert.NoError(t, err)
+	assert.Equal(t, "7", res)
+	_, err = trim("prj_1")
+	assert.Error(t, err, "Error expected")
+	_, err = trim(1)
+	assert.Error(t, err, "Error expected")
+
+	res, err = NewTrimAnyPrefixMap(true, "usr_")("prj_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "prj_1", res)
+}
+
+func TestTruncateMap(t *testing.T) {
+	t.Parallel()
+	truncate := NewTruncateMap(6, "…")
+	res, err := truncate(" short ")
+	assert.NoError(t, err)
+	assert.Equal(t, "short", res)
+	res, err = truncate("héllo wörld")
+	assert.NoError(t, err)
+	assert.Equal(t, "héllo…", res)
+	res, err = NewTruncateMap(2, "...")("abcdef")
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", res)
+	_, err = truncate(1)
+	assert.Error(t, err, "Error expected")
+	_, err = NewTruncateMap(-1, "")("abc")
+	assert.IsType(t, &InternalError{}, err)
+}
+
+func TestEnumNormalizeMap(t *testing.T) {
+	t.Parallel()
+	enum := NewEnumNormalizeMap("active", "Disabled")
+	for src, expected := range map[string]string{
+		"Active":   "active",
+		" ACTIVE ": "active",
+		"disabled": "Disabled",
+	} {
+		res, err := enum(src)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, res)
+	}
+	_, err := enum("deleted")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "[active Disabled]")
+	}
+	_, err = enum(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestStripControlCharsMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewStripControlCharsMap(false)(" a\x00b\tc\nd\x1b\u0085 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd", res)
+	res, err = NewStripControlCharsMap(true)("\x00 a\x00b\tc\nd\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "ab\tc\nd", res)
+	res, err = NewStripControlCharsMap(false)("héllo")
+	assert.NoError(t, err)
+	assert.Equal(t, "héllo", res)
+	_, err = NewStripControlCharsMap(false)(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestPathMap(t *testing.T) {
+	t.Parallel()
+	res, err := NewPathMap(true, true)(" /a/b/../c/ ")
+	assert.NoError(t, err)
+	assert.Equal(t, "/a/c", res)
+	res, err = NewPathMap(true, true)("/../etc")
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc", res)
+	res, err = NewPathMap(false, false)("a/../../b")
+	assert.NoError(t, err)
+	assert.Equal(t, "../b", res)
+	res, err = NewPathMap(false, true)("a//b/./c")
+	assert.NoError(t, err)
+	assert.Equal(t, "a/b/c", res)
+
+	for _, bad := range []interface{}{"a/b", "", 1} {
+		_, err := NewPathMap(true, false)(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+	_, err = NewPathMap(false, true)("a/../../b")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestRedactMap(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"user":     "User",
+		"password": Description{MapFunc: NewRedactMap("****"), Mandatory: true},
+	}
+	dst, err := Translate(map[string]interface{}{"user": "foo",
+		"password": "secret"}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"User": "foo",
+		"password": "****"}, dst)
+	_, err = Translate(map[string]interface{}{"user": "foo"}, descr)
+	assert.Error(t, err, "Error expected")
+	_, err = Translate(map[string]interface{}{"password": " "}, descr)
+	assert.Error(t, err, "Error expected")
+	_, err = NewRedactMap("****")(1234)
+	if assert.Error(t, err, "Error expected") {
+		assert.NotContains(t, err.Error(), "1234")
+	}
+}
+
+func TestEnumMap(t *testing.T) {
+	t.Parallel()
+	table := map[string]string{"A": "active", "D": "disabled"}
+	res, err := NewEnumMap(table, "")(" A ")
+	assert.NoError(t, err)
+	assert.Equal(t, "active", res)
+	_, err = NewEnumMap(table, "")("X")
+	assert.Error(t, err, "Error expected")
+	res, err = NewEnumMap(table, "other")("X")
+	assert.NoError(t, err)
+	assert.Equal(t, "other", res)
+	res, err = NewEnumMap(table, "other")("D")
+	assert.NoError(t, err)
+	assert.Equal(t, "disabled", res)
+	_, err = NewEnumMap(table, "other")(1)
+	assert.Error(t, err, "Error expected")
+}
+
+func TestUTF8Map(t *testing.T) {
+	t.Parallel()
+	res, err := UTF8Map(" héllo ")
+	assert.NoError(t, err)
+	assert.Equal(t, "héllo", res)
+	_, err = UTF8Map("a\xffb")
+	assert.Error(t, err, "Error expected")
+	_, err = UTF8Map(1)
+	assert.Error(t, err, "Error expected")
+	res, err = NewUTF8Map(true)(" a\xff\xfeb ")
+	assert.NoError(t, err)
+	assert.Equal(t, "a�b", res)
+}
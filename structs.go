@@ -0,0 +1,165 @@
+package maptrans
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DescriptionFromStruct builds a description from the `maptrans` tags of the
+// fields of a destination struct. This allows keeping the mapping next to the
+// type definition. The argument is a struct or a pointer to a struct; only its
+// type is used.
+//
+// The tag has the form `maptrans:"source,option,..."` where source is the name
+// of the source field. The target name is taken from the json tag, or the Go
+// field name if there is no json tag. An empty source means that the source
+// field has the same name as the target. Options are:
+//
+//	mandatory    - Mandatory
+//	skip_invalid - SkipInvalid
+//	nil_as_empty - NilAsEmpty
+//	omit_empty   - OmitEmpty
+//
+// Any other option is the name of a registered MapFunc (see RegisterMapFunc).
+// Names of built-in MapFuncs may be abbreviated by dropping the "Map" suffix
+// and ignoring case, so "uuid" refers to UUIDMap. For example:
+//
+//	type Interface struct {
+//		ID   string  `json:"id" maptrans:"uuid,uuid,mandatory"`
+//		Name string  `json:"name" maptrans:"display_name,string"`
+//		Info Info    `json:"info" maptrans:"info"`
+//		Hops []Route `json:"hops" maptrans:"routes,skip_invalid"`
+//	}
+//
+// Struct fields without a MapFunc option are translated using MapTranslation
+// with the description of the nested struct, slices of structs use
+// MapArrayTranslation. This only applies to structs with maptrans tags, so
+// opaque structs such as time.Time are treated as other fields, which are
+// copied with IDMap unless they have a MapFunc option. Fields without the
+// maptrans tag or with the tag "-" are skipped.
+func DescriptionFromStruct(v interface{}) (map[string]interface{}, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct", v)
+	}
+	return structDescription(t, "", map[reflect.Type]bool{})
+}
+
+// structDescription builds the description for the struct type t. The path
+// is used for error messages and visiting is used to detect recursive types.
+func structDescription(t reflect.Type, path string,
+	visiting map[reflect.Type]bool) (map[string]interface{}, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("%s: recursive type %v", path, t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	result := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("maptrans")
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		target := field.Name
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" &&
+			name != "-" {
+			target = name
+		}
+		parts := strings.Split(tag, ",")
+		source := strings.TrimSpace(parts[0])
+		if source == "" {
+			source = target
+		}
+		srcPath := fieldPath(path, source)
+		if _, isPresent := result[source]; isPresent {
+			return nil, fmt.Errorf("%s: duplicate source field", srcPath)
+		}
+		md := Description{TargetName: target}
+		for _, opt := range parts[1:] {
+			switch opt = strings.TrimSpace(opt); opt {
+			case "":
+			case "mandatory":
+				md.Mandatory = true
+			case "skip_invalid":
+				md.SkipInvalid = true
+			case "nil_as_empty":
+				md.NilAsEmpty = true
+			case "omit_empty":
+				md.OmitEmpty = true
+			default:
+				name, ok := mapFuncByTagName(opt)
+				if !ok {
+					return nil, fmt.Errorf("%s: unknown map func '%s'",
+						srcPath, opt)
+				}
+				md.MapFuncName = name
+			}
+		}
+		if md.MapFuncName == "" {
+			elemType, translationType := structFieldType(field.Type)
+			if elemType != nil {
+				sub, err := structDescription(elemType, srcPath, visiting)
+				if err != nil {
+					return nil, err
+				}
+				md.Type = translationType
+				md.SubTranslation = sub
+			} else {
+				md.MapFuncName = "IDMap"
+			}
+		}
+		result[source] = md
+	}
+	return result, nil
+}
+
+// structFieldType returns the struct type and the translation type for fields
+// holding a struct or a slice of structs with maptrans tags. For other fields
+// the struct type is nil.
+func structFieldType(t reflect.Type) (reflect.Type, TranslationType) {
+	translationType := MapTranslation
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+		translationType = MapArrayTranslation
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || !hasMaptransTags(t) {
+		return nil, CustomTranslation
+	}
+	return t, translationType
+}
+
+// hasMaptransTags returns true if any exported field of the struct type t has
+// the maptrans tag
+func hasMaptransTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup("maptrans"); ok && field.PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mapFuncByTagName returns the registered name of the MapFunc referred to by
+// a struct tag option
+func mapFuncByTagName(name string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if _, ok := registry[name]; ok {
+		return name, true
+	}
+	for k := range registry {
+		if strings.EqualFold(k, name+"Map") {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package maptrans
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type structTestRoute struct {
+	Gateway string `json:"gateway" maptrans:"gw,IPAddrMap,mandatory"`
+}
+
+type structTestInfo struct {
+	Port   string            `json:"port" maptrans:",integer"`
+	Routes []structTestRoute `json:"routes" maptrans:"routes,skip_invalid"`
+}
+
+type structTestObject struct {
+	ID       string          `json:"id" maptrans:"uuid,uuid,mandatory"`
+	Name     string          `json:"name" maptrans:"display_name,StringMap"`
+	Info     *structTestInfo `json:"info" maptrans:"info"`
+	Extra    interface{}     `maptrans:"extra"`
+	Internal string          `json:"internal"`
+	Skipped  string          `maptrans:"-"`
+}
+
+func TestDescriptionFromStruct(t *testing.T) {
+	t.Parallel()
+	descr, err := DescriptionFromStruct(&structTestObject{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	src := map[string]interface{}{
+		"uuid":         "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"display_name": " foo ",
+		"info": map[string]interface{}{
+			"port": 80,
+			"routes": []map[string]interface{}{
+				{"gw": "1.2.3.4"}, {"gw": "bad"},
+			},
+		},
+		"extra": []interface{}{1, "a"},
+	}
+	dst, err := TranslateStrict(src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":   "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"name": "foo",
+		"info": map[string]interface{}{
+			"port":   "80",
+			"routes": []map[string]interface{}{{"gateway": "1.2.3.4"}},
+		},
+		"Extra": []interface{}{1, "a"},
+	}, dst)
+
+	delete(src, "uuid")
+	_, err = Translate(src, descr)
+	assert.IsType(t, &MissingAttributeError{}, err)
+}
+
+type structTestEvent struct {
+	Name string    `json:"name" maptrans:"name"`
+	At   time.Time `json:"at" maptrans:"at"`
+}
+
+func TestDescriptionFromStructOpaque(t *testing.T) {
+	t.Parallel()
+	descr, err := DescriptionFromStruct(structTestEvent{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	dst, err := Translate(map[string]interface{}{
+		"name": "foo",
+		"at":   "2020-01-01T00:00:00Z",
+	}, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": "foo",
+		"at":   "2020-01-01T00:00:00Z",
+	}, dst)
+}
+
+type structTestBad struct {
+	Name string `maptrans:"name,nosuchfunc"`
+}
+
+type structTestRecursive struct {
+	Next *structTestRecursive `maptrans:"next"`
+}
+
+func TestDescriptionFromStructBad(t *testing.T) {
+	t.Parallel()
+	for _, v := range []interface{}{nil, 1, structTestBad{},
+		structTestRecursive{}} {
+		_, err := DescriptionFromStruct(v)
+		assert.Error(t, err, "Error expected for %T", v)
+	}
+}
@@ -0,0 +1,72 @@
+package maptrans
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NewTimeRangeMap returns a MapFunc that parses a timestamp using layout (see
+// time.Parse) and verifies that it is within [min, max]. Zero min or max
+// means that the range is unbounded on that side. The result is the trimmed
+// source string.
+func NewTimeRangeMap(min, max time.Time, layout string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		srcStr, ok := src.(string)
+		if !ok {
+			return "", fmt.Errorf("%v is not a string", src)
+		}
+		srcStr = strings.TrimSpace(srcStr)
+		ts, err := time.Parse(layout, srcStr)
+		if err != nil {
+			return "", fmt.Errorf("%s is not a valid time: %v", srcStr, err)
+		}
+		if !min.IsZero() && ts.Before(min) {
+			return "", fmt.Errorf("%s is before %s", srcStr,
+				min.Format(layout))
+		}
+		if !max.IsZero() && ts.After(max) {
+			return "", fmt.Errorf("%s is after %s", srcStr,
+				max.Format(layout))
+		}
+		return srcStr, nil
+	}
+}
+
+// Largest Unix time in seconds that can be formatted as RFC3339
+// (9999-12-31T23:59:59Z)
+const maxUnixSeconds = 253402300799
+
+// NewUnixTimeMap returns a MapFunc that converts Unix epoch time to an RFC3339
+// string in UTC. The unit is either "s" for seconds or "ms" for milliseconds.
+// The source can be an integer, an integral float or a numeric string.
+// Negative values and values past year 9999 are rejected. An unknown unit is
+// reported as InternalError for every value.
+func NewUnixTimeMap(unit string) MapFunc {
+	return func(src interface{}) (interface{}, error) {
+		val, err := toInt64(src)
+		if err != nil {
+			return "", err
+		}
+		if val < 0 {
+			return "", fmt.Errorf("%d should be non-negative", val)
+		}
+		var ts time.Time
+		switch unit {
+		case "s":
+			if val > maxUnixSeconds {
+				return "", fmt.Errorf("%d is out of range", val)
+			}
+			ts = time.Unix(val, 0)
+		case "ms":
+			if val/1000 > maxUnixSeconds {
+				return "", fmt.Errorf("%d is out of range", val)
+			}
+			ts = time.Unix(val/1000, (val%1000)*int64(time.Millisecond))
+		default:
+			return "", NewInternalError(
+				fmt.Sprintf("unknown time unit '%s'", unit))
+		}
+		return ts.UTC().Format(time.RFC3339Nano), nil
+	}
+}
@@ -0,0 +1,58 @@
+package maptrans
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeRangeMap(t *testing.T) {
+	t.Parallel()
+	min := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	max := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	inRange := NewTimeRangeMap(min, max, time.RFC3339)
+	res, err := inRange(" 2020-05-01T10:00:00Z ")
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-05-01T10:00:00Z", res)
+	_, err = inRange("0001-01-01T00:00:00Z")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "before 1970-01-01T00:00:00Z")
+	}
+	_, err = inRange("3000-01-01T00:00:00Z")
+	if assert.Error(t, err, "Error expected") {
+		assert.Contains(t, err.Error(), "after")
+	}
+	_, err = inRange("yesterday")
+	assert.Error(t, err, "Error expected")
+	_, err = inRange(1)
+	assert.Error(t, err, "Error expected")
+
+	unbounded := NewTimeRangeMap(time.Time{}, max, "2006-01-02")
+	_, err = unbounded("0001-01-01")
+	assert.NoError(t, err)
+	_, err = unbounded("2031-01-01")
+	assert.Error(t, err, "Error expected")
+}
+
+func TestUnixTimeMap(t *testing.T) {
+	t.Parallel()
+	seconds := NewUnixTimeMap("s")
+	res, err := seconds(1600000000)
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-09-13T12:26:40Z", res)
+	res, err = seconds(" 0 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "1970-01-01T00:00:00Z", res)
+	res, err = NewUnixTimeMap("ms")(1600000000123.0)
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-09-13T12:26:40.123Z", res)
+
+	for _, bad := range []interface{}{-1, "x", 1.5, int64(1) << 40} {
+		_, err := seconds(bad)
+		assert.Error(t, err, "Error expected for %v", bad)
+	}
+	_, err = NewUnixTimeMap("h")(1)
+	_, ok := err.(*InternalError)
+	assert.True(t, ok)
+}
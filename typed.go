@@ -0,0 +1,21 @@
+package maptrans
+
+import "github.com/goinggo/mapstructure"
+
+// TranslateTyped translates src using description and decodes the result into
+// a value of type T, typically a struct, using mapstructure. Result keys are
+// matched to struct fields by name (case insensitive) or by the
+// `mapstructure` tag. A result which can't be decoded into T is reported as
+// InternalError since it means that description and T don't agree.
+func TranslateTyped[T any](src map[string]interface{},
+	description map[string]interface{}) (T, error) {
+	var result T
+	dst, err := Translate(src, description)
+	if err != nil {
+		return result, err
+	}
+	if err := mapstructure.Decode(dst, &result); err != nil {
+		return result, NewInternalError(err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedTestRoute struct {
+	Gateway string
+}
+
+type typedTestObject struct {
+	Name   string
+	ID     string           `mapstructure:"id"`
+	Force  bool             `mapstructure:"force"`
+	Routes []typedTestRoute `mapstructure:"routes"`
+}
+
+func TestTranslateTyped(t *testing.T) {
+	t.Parallel()
+	descr := map[string]interface{}{
+		"name":  "Name",
+		"id":    Description{MapFunc: IntegerMap},
+		"force": Description{MapFunc: BoolMap},
+		"routes": Description{
+			Type:           MapArrayTranslation,
+			SubTranslation: map[string]interface{}{"gw": "Gateway"},
+		},
+	}
+	src := map[string]interface{}{
+		"name":   "foo",
+		"id":     1,
+		"force":  "true",
+		"routes": []interface{}{map[string]interface{}{"gw": "1.2.3.4"}},
+	}
+	obj, err := TranslateTyped[typedTestObject](src, descr)
+	assert.NoError(t, err)
+	assert.Equal(t, typedTestObject{
+		Name:   "foo",
+		ID:     "1",
+		Force:  true,
+		Routes: []typedTestRoute{{Gateway: "1.2.3.4"}},
+	}, obj)
+
+	src["id"] = "x"
+	_, err = TranslateTyped[typedTestObject](src, descr)
+	assert.IsType(t, &InvalidPropertyError{}, err)
+
+	src["id"] = 1
+	_, err = TranslateTyped[int](src, descr)
+	assert.IsType(t, &InternalError{}, err)
+}
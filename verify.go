@@ -0,0 +1,119 @@
+package maptrans
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// VerifyRoundTrip verifies that translating src with the forward description
+// and then translating the result with the reverse description reproduces
+// src. Values are compared using IsSimilar rules: fields produced by the
+// reverse description must be present in the round-tripped result and match
+// the original values. Fields described with CustomTranslation are compared
+// using their CompareFunc or, if it isn't set, for deep equality. Every field
+// of src which is translated by the forward description, including fields of
+// nested objects, must be present in the round-tripped result, so fields lost
+// on the way are reported as MissingAttributeError.
+func VerifyRoundTrip(src map[string]interface{},
+	forward map[string]interface{},
+	reverse map[string]interface{}) (bool, error) {
+	dst, err := Translate(src, forward)
+	if err != nil {
+		return false, err
+	}
+	back, err := Translate(dst, reverse)
+	if err != nil {
+		return false, err
+	}
+	if missing, ok := missingField(src, back, forward, ""); ok {
+		return false, NewMissingAttributeError(missing)
+	}
+	return IsSimilar(src, back, identityDescription(reverse))
+}
+
+// missingField returns the path of the first field of src translated by
+// description which is missing from back
+func missingField(src, back map[string]interface{},
+	description map[string]interface{}, path string) (string, bool) {
+	if description == nil {
+		return "", false
+	}
+	patterns := descriptionPatterns(description)
+	for _, k := range orderedKeys(src, nil) {
+		mapDescr, ok := resolveDescription(description, patterns, k)
+		if !ok || mapDescr == nil {
+			continue
+		}
+		md, isDescr := mapDescr.(Description)
+		if isDescr && (md.Type == IgnoreTranslation ||
+			md.Type == InsertTranslation) {
+			continue
+		}
+		vBack, isPresent := back[k]
+		if !isPresent {
+			return fieldPath(path, k), true
+		}
+		if !isDescr || md.Type != MapTranslation {
+			continue
+		}
+		mapSrc, okSrc := src[k].(map[string]interface{})
+		mapBack, okBack := vBack.(map[string]interface{})
+		if okSrc && okBack {
+			missing, ok := missingField(mapSrc, mapBack, md.SubTranslation,
+				fieldPath(path, k))
+			if ok {
+				return missing, true
+			}
+		}
+	}
+	return "", false
+}
+
+// identityDescription builds a description that maps every target of the
+// given description to itself. It is used to compare results of the
+// description with the original objects.
+func identityDescription(
+	description map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range description {
+		if name, ok := v.(string); ok {
+			result[name] = name
+			continue
+		}
+		md, ok := v.(Description)
+		if !ok {
+			continue
+		}
+		targetName := md.TargetName
+		if targetName == "" {
+			targetName = k
+		}
+		switch md.Type {
+		case CustomTranslation:
+			compare := md.CompareFunc
+			if compare == nil {
+				compare = deepEqualCompare
+			}
+			result[targetName] = Description{
+				TargetName:  targetName,
+				CompareFunc: compare,
+			}
+		case MapTranslation, MapArrayTranslation:
+			result[targetName] = Description{
+				TargetName:     targetName,
+				Type:           md.Type,
+				CompareFunc:    md.CompareFunc,
+				SubTranslation: identityDescription(md.SubTranslation),
+			}
+		}
+	}
+	return result
+}
+
+// deepEqualCompare is a CompareFunc that uses reflect.DeepEqual
+func deepEqualCompare(src interface{}, dst interface{}) (bool, error) {
+	if !reflect.DeepEqual(src, dst) {
+		return false, fmt.Errorf("Values %v and %v don't match", src, dst)
+	}
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package maptrans
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRoundTrip(t *testing.T) {
+	t.Parallel()
+	forward := map[string]interface{}{
+		"name": "Name",
+		"id":   Description{TargetName: "ID", MapFunc: UUIDMap},
+		"info": Description{
+			TargetName: "Info",
+			Type:       MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"ip": "IP",
+			},
+		},
+	}
+	reverse := map[string]interface{}{
+		"Name": "name",
+		"ID":   Description{TargetName: "id", MapFunc: UUIDMap},
+		"Info": Description{
+			TargetName: "info",
+			Type:       MapTranslation,
+			SubTranslation: map[string]interface{}{
+				"IP": "ip",
+			},
+		},
+	}
+	src := map[string]interface{}{
+		"name": "foo",
+		"id":   "cb89a4a9-7a7e-59ea-a0f2-5e2a2c2b6c73",
+		"info": map[string]interface{}{"ip": "1.2.3.4"},
+	}
+	r, err := VerifyRoundTrip(src, forward, reverse)
+	assert.NoError(t, err)
+	assert.True(t, r)
+
+	// Lossy mapping: the forward description drops the IP address
+	lossy := map[string]interface{}{
+		"name": "Name",
+		"id":   Description{TargetName: "ID", MapFunc: UUIDMap},
+		"info": Description{
+			TargetName:     "Info",
+			Type:           MapTranslation,
+			SubTranslation: map[string]interface{}{},
+		},
+	}
+	r, err = VerifyRoundTrip(src, lossy, reverse)
+	assert.Error(t, err, "Error expected")
+	assert.False(t, r)
+
+	// Lossy mapping: the reverse description doesn't restore "b"
+	r, err = VerifyRoundTrip(map[string]interface{}{"a": "x", "b": "y"},
+		map[string]interface{}{"a": "A", "b": "B"},
+		map[string]interface{}{"A": "a"})
+	assert.IsType(t, &MissingAttributeError{}, err)
+	assert.False(t, r)
+}
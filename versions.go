@@ -0,0 +1,61 @@
+package maptrans
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// SelectDescription picks a description from versions based on the version
+// found in the versionField of src. Numeric versions of any numeric type,
+// including json.Number, are converted to strings, so the version 2 or 2.0
+// selects the description with the key "2".
+//
+// If src doesn't have versionField, the description for defaultVersion is
+// used; an empty defaultVersion makes the version field mandatory and its
+// absence is reported as MissingAttributeError. An unknown version is
+// reported as InvalidPropertyError.
+func SelectDescription(src map[string]interface{}, versionField string,
+	versions map[string]map[string]interface{},
+	defaultVersion string) (map[string]interface{}, error) {
+	version := defaultVersion
+	if val, isPresent := src[versionField]; isPresent {
+		if str, ok := val.(string); ok {
+			version = str
+		} else if i, err := toInt64(val); err == nil {
+			version = strconv.FormatInt(i, 10)
+		} else if f, err := toFloat64(val); err == nil {
+			version = fmt.Sprint(f)
+		} else {
+			return nil, NewInvalidProp(versionField,
+				fmt.Sprintf("invalid version: %v", err))
+		}
+	} else if version == "" {
+		return nil, NewMissingAttributeError(versionField)
+	}
+	description, ok := versions[version]
+	if !ok {
+		known := make([]string, 0, len(versions))
+		for k := range versions {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return nil, NewInvalidProp(versionField,
+			fmt.Sprintf("unknown version '%s', expected one of %v",
+				version, known))
+	}
+	return description, nil
+}
+
+// TranslateVersioned selects the description using SelectDescription and
+// translates src with it.
+func TranslateVersioned(src map[string]interface{}, versionField string,
+	versions map[string]map[string]interface{},
+	defaultVersion string) (map[string]interface{}, error) {
+	description, err := SelectDescription(src, versionField, versions,
+		defaultVersion)
+	if err != nil {
+		return nil, err
+	}
+	return Translate(src, description)
+}
@@ -0,0 +1,65 @@
+package maptrans
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateVersioned(t *testing.T) {
+	t.Parallel()
+	versions := map[string]map[string]interface{}{
+		"1": {"name": "Name"},
+		"2": {"full_name": "Name"},
+	}
+	dst, err := TranslateVersioned(map[string]interface{}{
+		"schema_version": "2", "full_name": "foo",
+	}, "schema_version", versions, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst)
+
+	dst, err = TranslateVersioned(map[string]interface{}{
+		"schema_version": 2.0, "full_name": "foo",
+	}, "schema_version", versions, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst)
+
+	for _, v := range []json.Number{"2", "2.0"} {
+		dst, err = TranslateVersioned(map[string]interface{}{
+			"schema_version": v, "full_name": "foo",
+		}, "schema_version", versions, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst)
+	}
+	for _, v := range []interface{}{int32(2), uint(2), uint8(2), float32(2)} {
+		descr, err := SelectDescription(map[string]interface{}{
+			"schema_version": v,
+		}, "schema_version", versions, "1")
+		assert.NoError(t, err, "%T", v)
+		assert.Equal(t, versions["2"], descr)
+	}
+	_, err = SelectDescription(map[string]interface{}{
+		"schema_version": json.Number("x"),
+	}, "schema_version", versions, "1")
+	assert.IsType(t, &InvalidPropertyError{}, err)
+
+	dst, err = TranslateVersioned(map[string]interface{}{"name": "foo"},
+		"schema_version", versions, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "foo"}, dst)
+
+	_, err = TranslateVersioned(map[string]interface{}{"name": "foo"},
+		"schema_version", versions, "")
+	assert.IsType(t, &MissingAttributeError{}, err)
+	_, err = TranslateVersioned(map[string]interface{}{
+		"schema_version": "3",
+	}, "schema_version", versions, "1")
+	if assert.IsType(t, &InvalidPropertyError{}, err) {
+		assert.Contains(t, err.Error(), "[1 2]")
+	}
+	_, err = SelectDescription(map[string]interface{}{
+		"schema_version": true,
+	}, "schema_version", versions, "1")
+	assert.Error(t, err, "Error expected")
+}